@@ -8,7 +8,11 @@ import (
 	"github.com/fatlirmorina/notifypipe/internal/config"
 	"github.com/fatlirmorina/notifypipe/internal/database"
 	"github.com/fatlirmorina/notifypipe/internal/docker"
+	"github.com/fatlirmorina/notifypipe/internal/heartbeats"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
 	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/fatlirmorina/notifypipe/internal/reports"
+	"github.com/fatlirmorina/notifypipe/internal/retention"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -39,11 +43,15 @@ func main() {
 	}
 	defer dockerClient.Close()
 
+	// Initialize live update hub
+	eventHub := hub.New(dockerClient.HostName())
+
 	// Initialize notification manager
-	notificationManager := notifications.NewManager(db)
+	notificationManager := notifications.NewManager(db, eventHub, cfg)
+	go notificationManager.Start()
 
 	// Initialize event monitor
-	eventMonitor := docker.NewEventMonitor(dockerClient, db, notificationManager)
+	eventMonitor := docker.NewEventMonitor(dockerClient, db, notificationManager, eventHub, cfg)
 
 	// Start monitoring Docker events in background
 	go func() {
@@ -52,6 +60,18 @@ func main() {
 		}
 	}()
 
+	// Start pruning old events in background
+	pruner := retention.NewPruner(db, cfg)
+	go pruner.Start()
+
+	// Send scheduled uptime reports in background
+	reportScheduler := reports.NewScheduler(db, notificationManager, cfg)
+	go reportScheduler.Start()
+
+	// Watch for late heartbeats in background
+	heartbeatScheduler := heartbeats.NewScheduler(db, notificationManager, eventHub, cfg)
+	go heartbeatScheduler.Start()
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "NotifyPipe v1.0.2",
@@ -70,9 +90,25 @@ func main() {
 	app.Static("/", "./web/dist")
 
 	// API routes
-	apiRouter := api.NewRouter(app, db, dockerClient, notificationManager, cfg)
+	apiRouter := api.NewRouter(app, db, dockerClient, eventMonitor, notificationManager, eventHub, pruner, heartbeatScheduler, cfg)
 	apiRouter.Setup()
 
+	// Serve metrics on a separate port if configured
+	if cfg.MetricsPort != "" {
+		metricsApp := fiber.New(fiber.Config{
+			DisableStartupMessage: true,
+			ServerHeader:          "NotifyPipe",
+		})
+		metricsApp.Get("/metrics", api.MetricsHandler(dockerClient))
+
+		go func() {
+			log.Printf("📈 Metrics: http://localhost:%s/metrics", cfg.MetricsPort)
+			if err := metricsApp.Listen(":" + cfg.MetricsPort); err != nil {
+				log.Printf("Failed to start metrics server: %v", err)
+			}
+		}()
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
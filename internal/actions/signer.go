@@ -0,0 +1,127 @@
+package actions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// Actions that can be performed from a notification
+const (
+	Acknowledge = "ack"
+	Mute        = "mute"
+	Restart     = "restart"
+)
+
+// secretKey is the settings key of the generated signing secret
+const secretKey = "action_secret"
+
+// Request is a single action on a container
+type Request struct {
+	Action    string
+	Container string
+	Name      string
+	Incident  string
+	Duration  time.Duration
+	Expires   time.Time
+	Signature string
+}
+
+// Signer creates and verifies signed, expiring action URLs
+type Signer struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewSigner creates a signer using ACTION_SECRET, or a secret generated once
+// and kept in the settings collection so links survive restarts
+func NewSigner(db *database.Database, cfg *config.Config) *Signer {
+	secret := cfg.ActionSecret
+	if secret == "" {
+		secret = db.GetSetting(secretKey)
+	}
+	if secret == "" {
+		secret = security.RandomString(48)
+		if err := db.SetSetting(secretKey, secret); err != nil {
+			log.Printf("Error saving action secret, action links will expire on restart: %v", err)
+		}
+	}
+
+	return &Signer{
+		secret:  []byte(secret),
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		ttl:     cfg.ActionTTL,
+	}
+}
+
+// URL returns a signed URL that performs an action until the TTL expires
+func (s *Signer) URL(req Request) string {
+	req.Expires = time.Now().Add(s.ttl)
+
+	query := url.Values{}
+	query.Set("container", req.Container)
+	if req.Name != "" {
+		query.Set("name", req.Name)
+	}
+	if req.Incident != "" {
+		query.Set("incident", req.Incident)
+	}
+	if req.Duration > 0 {
+		query.Set("duration", req.Duration.String())
+	}
+	query.Set("expires", strconv.FormatInt(req.Expires.Unix(), 10))
+	query.Set("sig", s.sign(req))
+
+	return s.baseURL + "/api/actions/" + req.Action + "?" + query.Encode()
+}
+
+// Verify parses an action from its URL parts and checks its signature and
+// expiry
+func (s *Signer) Verify(action string, query url.Values) (*Request, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link")
+	}
+
+	req := &Request{
+		Action:    action,
+		Container: query.Get("container"),
+		Name:      query.Get("name"),
+		Incident:  query.Get("incident"),
+		Expires:   time.Unix(expires, 0),
+		Signature: query.Get("sig"),
+	}
+	if value := query.Get("duration"); value != "" {
+		if req.Duration, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("invalid link")
+		}
+	}
+
+	if !hmac.Equal([]byte(s.sign(*req)), []byte(req.Signature)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	if time.Now().After(req.Expires) {
+		return nil, fmt.Errorf("this link has expired")
+	}
+
+	return req, nil
+}
+
+// sign computes the signature of every field of a request
+func (s *Signer) sign(req Request) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%d\n%d",
+		req.Action, req.Container, req.Name, req.Incident, int64(req.Duration), req.Expires.Unix())
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
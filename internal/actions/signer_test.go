@@ -0,0 +1,93 @@
+package actions
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseActionURL splits a signed URL into its action and query
+func parseActionURL(t *testing.T, link string) (string, url.Values) {
+	t.Helper()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", link, err)
+	}
+	return strings.TrimPrefix(u.Path, "/api/actions/"), u.Query()
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := &Signer{secret: []byte("secret"), baseURL: "https://notify.example.com", ttl: time.Hour}
+	want := Request{Action: Mute, Container: "abc123", Name: "web", Incident: "inc1", Duration: time.Hour}
+
+	link := signer.URL(want)
+	if !strings.HasPrefix(link, "https://notify.example.com/api/actions/mute?") {
+		t.Fatalf("URL() = %q, want a mute link on the base URL", link)
+	}
+
+	action, query := parseActionURL(t, link)
+	got, err := signer.Verify(action, query)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.Action != want.Action || got.Container != want.Container || got.Name != want.Name ||
+		got.Incident != want.Incident || got.Duration != want.Duration {
+		t.Errorf("Verify() = %+v, want %+v", got, want)
+	}
+	if got.Signature != query.Get("sig") {
+		t.Errorf("Verify().Signature = %q, want %q", got.Signature, query.Get("sig"))
+	}
+}
+
+func TestSignerVerifyRejects(t *testing.T) {
+	signer := &Signer{secret: []byte("secret"), ttl: time.Hour}
+	link := signer.URL(Request{Action: Mute, Container: "abc123", Name: "web", Duration: time.Hour})
+
+	tests := []struct {
+		name   string
+		action string
+		change func(url.Values)
+		want   string
+	}{
+		{"changed action", Restart, func(url.Values) {}, "invalid signature"},
+		{"changed container", Mute, func(q url.Values) { q.Set("container", "def456") }, "invalid signature"},
+		{"changed duration", Mute, func(q url.Values) { q.Set("duration", "24h0m0s") }, "invalid signature"},
+		{"added incident", Mute, func(q url.Values) { q.Set("incident", "inc1") }, "invalid signature"},
+		{"changed expiry", Mute, func(q url.Values) { q.Set("expires", "9999999999") }, "invalid signature"},
+		{"bad signature", Mute, func(q url.Values) { q.Set("sig", "not-a-signature") }, "invalid signature"},
+		{"missing signature", Mute, func(q url.Values) { q.Del("sig") }, "invalid signature"},
+		{"invalid duration", Mute, func(q url.Values) { q.Set("duration", "soon") }, "invalid link"},
+		{"missing expiry", Mute, func(q url.Values) { q.Del("expires") }, "invalid link"},
+	}
+
+	for _, tt := range tests {
+		_, query := parseActionURL(t, link)
+		tt.change(query)
+
+		_, err := signer.Verify(tt.action, query)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: Verify() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSignerVerifyOtherSecret(t *testing.T) {
+	signer := &Signer{secret: []byte("secret"), ttl: time.Hour}
+	other := &Signer{secret: []byte("other"), ttl: time.Hour}
+
+	action, query := parseActionURL(t, other.URL(Request{Action: Restart, Container: "abc123"}))
+	if _, err := signer.Verify(action, query); err == nil || err.Error() != "invalid signature" {
+		t.Errorf("Verify() error = %v, want invalid signature", err)
+	}
+}
+
+func TestSignerVerifyExpired(t *testing.T) {
+	signer := &Signer{secret: []byte("secret"), ttl: -time.Minute}
+
+	action, query := parseActionURL(t, signer.URL(Request{Action: Acknowledge, Container: "abc123", Incident: "inc1"}))
+	if _, err := signer.Verify(action, query); err == nil || err.Error() != "this link has expired" {
+		t.Errorf("Verify() error = %v, want this link has expired", err)
+	}
+}
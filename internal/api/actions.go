@@ -0,0 +1,232 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/actions"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// actionTitles describes each action on the confirmation page
+var actionTitles = map[string]string{
+	actions.Acknowledge: "Acknowledge incident",
+	actions.Mute:        "Mute notifications",
+	actions.Restart:     "Restart container",
+}
+
+// actionPage is shown for action links. Links are opened with GET, which chat
+// apps may prefetch for previews, so actions only run after the form posts.
+var actionPage = template.Must(template.New("action").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - NotifyPipe</title>
+</head>
+<body style="font-family: sans-serif; background: #0f172a; color: #e2e8f0; display: flex; justify-content: center; padding-top: 10vh">
+<div style="max-width: 420px; text-align: center">
+<h1 style="font-size: 1.4em">{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Confirm}}
+<form method="POST">
+<button type="submit" style="background: #2563eb; color: white; border: 0; border-radius: 6px; padding: 10px 24px; font-size: 1em; cursor: pointer">{{.Confirm}}</button>
+</form>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+// showAction shows a confirmation page for a signed action link
+func (r *Router) showAction(c *fiber.Ctx) error {
+	req, err := r.verifyAction(c)
+	if err != nil {
+		return r.renderAction(c, 403, "Action unavailable", err.Error(), "")
+	}
+	if r.actionUsed(req) {
+		return r.renderAction(c, 403, "Action unavailable", "this link was already used", "")
+	}
+
+	name := req.Name
+	if name == "" {
+		name = shortID(req.Container)
+	}
+
+	message := fmt.Sprintf("Container %s", name)
+	if req.Action == actions.Mute {
+		message = fmt.Sprintf("Mute notifications for %s for %s", name, req.Duration)
+	}
+
+	return r.renderAction(c, 200, actionTitles[req.Action], message, "Confirm")
+}
+
+// performAction performs a signed action after confirmation
+func (r *Router) performAction(c *fiber.Ctx) error {
+	req, err := r.verifyAction(c)
+	if err != nil {
+		return r.renderAction(c, 403, "Action unavailable", err.Error(), "")
+	}
+
+	r.actionsMu.Lock()
+	defer r.actionsMu.Unlock()
+
+	if r.actionUsed(req) {
+		return r.renderAction(c, 403, "Action unavailable", "this link was already used", "")
+	}
+
+	var message string
+	switch req.Action {
+	case actions.Acknowledge:
+		message, err = r.acknowledge(req)
+	case actions.Mute:
+		message, err = r.mute(req)
+	case actions.Restart:
+		message, err = r.restart(req)
+	}
+	if err != nil {
+		return r.renderAction(c, 500, actionTitles[req.Action], "Failed: "+err.Error(), "")
+	}
+
+	if singleUseAction(req.Action) {
+		if err := r.db.SetSetting(usedActionKey(req), time.Now().UTC().Format(time.RFC3339)); err != nil {
+			log.Printf("Error saving used action link: %v", err)
+		}
+	}
+
+	return r.renderAction(c, 200, actionTitles[req.Action], message, "")
+}
+
+// singleUseAction reports whether links for an action only work once.
+// Acknowledging twice is harmless, so only mute and restart links are.
+func singleUseAction(action string) bool {
+	return action != actions.Acknowledge
+}
+
+// actionUsed reports whether a single-use action link was already used
+func (r *Router) actionUsed(req *actions.Request) bool {
+	return singleUseAction(req.Action) && r.db.GetSetting(usedActionKey(req)) != ""
+}
+
+// usedActionKey is the settings key marking an action link as used
+func usedActionKey(req *actions.Request) string {
+	return "action_used_" + req.Signature
+}
+
+// verifyAction checks the signature and expiry of an action link
+func (r *Router) verifyAction(c *fiber.Ctx) (*actions.Request, error) {
+	action := c.Params("action")
+	if _, ok := actionTitles[action]; !ok {
+		return nil, fmt.Errorf("unknown action")
+	}
+
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid link")
+	}
+
+	return r.notifier.Actions().Verify(action, query)
+}
+
+// acknowledge records that someone is looking at an incident
+func (r *Router) acknowledge(req *actions.Request) (string, error) {
+	if req.Incident == "" {
+		return "", fmt.Errorf("invalid acknowledge request")
+	}
+
+	acked, err := r.db.CountRecordsByFilter("events_log", "event_type = 'ack' && incident_id = {:incident}", dbx.Params{
+		"incident": req.Incident,
+	})
+	if err != nil {
+		return "", err
+	}
+	if acked > 0 {
+		return "This incident was already acknowledged.", nil
+	}
+
+	_, err = r.saveEvent(req.Container, req.Name, "ack", "info", "Incident acknowledged", req.Incident, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return "Incident acknowledged.", nil
+}
+
+// mute silences notifications for a container for the signed duration
+func (r *Router) mute(req *actions.Request) (string, error) {
+	if req.Name == "" || req.Duration <= 0 {
+		return "", fmt.Errorf("invalid mute request")
+	}
+
+	collection, err := r.db.App().Dao().FindCollectionByNameOrId("silences")
+	if err != nil {
+		return "", err
+	}
+
+	until := time.Now().Add(req.Duration)
+
+	record := models.NewRecord(collection)
+	record.Set("container_name", req.Name)
+	record.Set("until", until)
+	record.Set("reason", "Muted from a notification")
+	if err := r.db.App().Dao().SaveRecord(record); err != nil {
+		return "", err
+	}
+
+	r.saveEvent(req.Container, req.Name, "mute", "info", fmt.Sprintf("Notifications muted for %s", req.Duration), "", time.Now())
+
+	return fmt.Sprintf("Notifications for %s are muted until %s.", req.Name, until.UTC().Format(time.RFC1123)), nil
+}
+
+// restart restarts a container through the Docker API
+func (r *Router) restart(req *actions.Request) (string, error) {
+	if r.docker == nil {
+		return "", fmt.Errorf("docker is not available")
+	}
+
+	info, err := r.docker.GetContainer(req.Container)
+	if err != nil {
+		return "", fmt.Errorf("container not found")
+	}
+	if err := r.checkControl(info, "restart"); err != nil {
+		return "", err
+	}
+
+	err = r.docker.RestartContainer(info.ID, nil)
+	r.auditControl(info, "restart", "a notification link", err)
+	if err != nil {
+		return "", err
+	}
+
+	return "Container restarted.", nil
+}
+
+// renderAction renders the action page
+func (r *Router) renderAction(c *fiber.Ctx, status int, title, message, confirm string) error {
+	var buf bytes.Buffer
+	err := actionPage.Execute(&buf, fiber.Map{
+		"Title":   title,
+		"Message": message,
+		"Confirm": confirm,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
+
+// shortID shortens a container ID for display
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -3,12 +3,21 @@ package api
 import (
 	"strings"
 
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/docker"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/pocketbase/pocketbase/models"
 )
 
-// listContainers returns all containers
+// listContainers returns a page of containers, optionally filtered by state
+// (comma separated), image and q, which searches names and images
 func (r *Router) listContainers(c *fiber.Ctx) error {
+	page := parsePagination(c)
+	states := splitList(c.Query("state"))
+	image := c.Query("image")
+	q := strings.ToLower(strings.TrimSpace(c.Query("q")))
+
 	// Get containers from Docker
 	dockerContainers, err := r.docker.ListContainers()
 	if err != nil {
@@ -18,7 +27,7 @@ func (r *Router) listContainers(c *fiber.Ctx) error {
 	// Get container settings from database
 	dbRecords, err := r.db.App().Dao().FindRecordsByFilter(
 		"containers",
-		"",
+		database.MatchAll,
 		"",
 		0,
 		0,
@@ -36,34 +45,56 @@ func (r *Router) listContainers(c *fiber.Ctx) error {
 
 	// Combine data
 	var result []fiber.Map
+	total := 0
 	for _, container := range dockerContainers {
 		settings := settingsMap[container.ID]
-		
+
 		name := ""
 		if len(container.Names) > 0 {
 			name = strings.TrimPrefix(container.Names[0], "/")
 		}
 
+		if len(states) > 0 && !contains(states, container.State) {
+			continue
+		}
+		if image != "" && container.Image != image {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(name), q) &&
+			!strings.Contains(strings.ToLower(container.Image), q) {
+			continue
+		}
+
+		// Only build items for the requested page, but count every match
+		total++
+		if total <= page.offset() || total > page.offset()+page.PerPage {
+			continue
+		}
+
 		item := fiber.Map{
-			"id":                 container.ID,
-			"name":               name,
-			"image":              container.Image,
-			"state":              container.State,
-			"status":             container.Status,
-			"created":            container.Created,
-			"notify_on_success":  false,
-			"notify_on_failure":  true,
+			"id":                container.ID,
+			"name":              name,
+			"image":             container.Image,
+			"state":             container.State,
+			"status":            container.Status,
+			"created":           container.Created,
+			"notify_on_success": false,
+			"notify_on_failure": true,
 		}
 
 		if settings != nil {
 			item["notify_on_success"] = settings.GetBool("notify_on_success")
 			item["notify_on_failure"] = settings.GetBool("notify_on_failure")
+			item["notify_on_actions"] = notifyOnActions(settings)
+		}
+		if usage, ok := r.monitor.Usage(container.ID); ok {
+			item["usage"] = usage
 		}
 
 		result = append(result, item)
 	}
 
-	return c.JSON(result)
+	return c.JSON(page.response(result, total))
 }
 
 // getContainer returns a specific container
@@ -78,7 +109,7 @@ func (r *Router) getContainer(c *fiber.Ctx) error {
 	// Get settings from database
 	records, err := r.db.App().Dao().FindRecordsByFilter(
 		"containers",
-		"",
+		database.MatchAll,
 		"",
 		0,
 		0,
@@ -96,18 +127,24 @@ func (r *Router) getContainer(c *fiber.Ctx) error {
 	}
 
 	result := fiber.Map{
-		"id":                 containerInfo.ID,
-		"name":               strings.TrimPrefix(containerInfo.Name, "/"),
-		"image":              containerInfo.Config.Image,
-		"state":              containerInfo.State.Status,
-		"created":            containerInfo.Created,
-		"notify_on_success":  false,
-		"notify_on_failure":  true,
+		"id":                containerInfo.ID,
+		"name":              strings.TrimPrefix(containerInfo.Name, "/"),
+		"image":             containerInfo.Config.Image,
+		"state":             containerInfo.State.Status,
+		"created":           containerInfo.Created,
+		"notify_on_success": false,
+		"notify_on_failure": true,
 	}
 
 	if settings != nil {
 		result["notify_on_success"] = settings.GetBool("notify_on_success")
 		result["notify_on_failure"] = settings.GetBool("notify_on_failure")
+		result["notify_on_actions"] = notifyOnActions(settings)
+		result["lifecycle_status"] = settings.GetString("status")
+		result["probes"] = containerProbes(settings)
+	}
+	if usage, ok := r.monitor.Usage(containerInfo.ID); ok {
+		result["usage"] = usage
 	}
 
 	return c.JSON(result)
@@ -118,18 +155,34 @@ func (r *Router) updateContainer(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	var body struct {
-		NotifyOnSuccess bool `json:"notify_on_success"`
-		NotifyOnFailure bool `json:"notify_on_failure"`
+		NotifyOnSuccess bool            `json:"notify_on_success"`
+		NotifyOnFailure bool            `json:"notify_on_failure"`
+		NotifyOnActions *[]string       `json:"notify_on_actions"`
+		Probes          *[]docker.Probe `json:"probes"`
 	}
 
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
+	if body.NotifyOnActions != nil {
+		for _, action := range *body.NotifyOnActions {
+			if !contains(docker.LifecycleActions, action) {
+				return c.Status(400).JSON(fiber.Map{"error": "Unknown action " + action + ", expected one of " + strings.Join(docker.LifecycleActions, ", ")})
+			}
+		}
+	}
+	if body.Probes != nil {
+		for _, probe := range *body.Probes {
+			if err := probe.Validate(); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	}
 
 	// Find or create container record
 	records, err := r.db.App().Dao().FindRecordsByFilter(
 		"containers",
-		"",
+		database.MatchAll,
 		"",
 		0,
 		0,
@@ -150,6 +203,12 @@ func (r *Router) updateContainer(c *fiber.Ctx) error {
 		// Update existing
 		existingRecord.Set("notify_on_success", body.NotifyOnSuccess)
 		existingRecord.Set("notify_on_failure", body.NotifyOnFailure)
+		if body.NotifyOnActions != nil {
+			existingRecord.Set("notify_on_actions", *body.NotifyOnActions)
+		}
+		if body.Probes != nil {
+			existingRecord.Set("probes", *body.Probes)
+		}
 		if err := r.db.App().Dao().SaveRecord(existingRecord); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -171,14 +230,44 @@ func (r *Router) updateContainer(c *fiber.Ctx) error {
 		record.Set("image", containerInfo.Config.Image)
 		record.Set("notify_on_success", body.NotifyOnSuccess)
 		record.Set("notify_on_failure", body.NotifyOnFailure)
+		record.Set("status", containerInfo.State.Status)
+		if body.NotifyOnActions != nil {
+			record.Set("notify_on_actions", *body.NotifyOnActions)
+		}
+		if body.Probes != nil {
+			record.Set("probes", *body.Probes)
+		}
 
 		if err := r.db.App().Dao().SaveRecord(record); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 	}
 
+	if body.Probes != nil {
+		r.monitor.WatchProbes(id)
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Container settings updated",
 	})
 }
+
+// notifyOnActions returns the lifecycle actions a container is notified on,
+// or nil if it uses the NOTIFY_ACTIONS default
+func notifyOnActions(record *models.Record) []string {
+	var actions []string
+	if err := record.UnmarshalJSONField("notify_on_actions", &actions); err != nil {
+		return nil
+	}
+	return actions
+}
+
+// containerProbes returns the probes set for a container through the API
+func containerProbes(record *models.Record) []docker.Probe {
+	var probes []docker.Probe
+	if err := record.UnmarshalJSONField("probes", &probes); err != nil {
+		return nil
+	}
+	return probes
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// controlLabel opts a container out of control actions when set to "false"
+const controlLabel = "notifypipe.control"
+
+// controlActions are the container actions the API can perform
+var controlActions = []string{"start", "stop", "restart", "kill"}
+
+// requireControlToken rejects cross-origin requests and requests without a
+// configured control token, passed like an ingest token. Control routes
+// change containers, so a page on another site must never reach them.
+func (r *Router) requireControlToken(c *fiber.Ctx) error {
+	if crossOrigin(c) {
+		return c.Status(403).JSON(fiber.Map{"error": "Cross-origin requests are not allowed"})
+	}
+	if len(r.config.ControlTokens) == 0 {
+		return c.Status(403).JSON(fiber.Map{"error": "Container control is disabled, set CONTROL_TOKENS to enable it"})
+	}
+	if !validToken(requestToken(c), r.config.ControlTokens) {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid control token"})
+	}
+	return c.Next()
+}
+
+// crossOrigin reports whether a browser sent a request from another site
+func crossOrigin(c *fiber.Ctx) bool {
+	if site := c.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return true
+	}
+	if origin := c.Get(fiber.HeaderOrigin); origin != "" {
+		u, err := url.Parse(origin)
+		return err != nil || u.Host != c.Hostname()
+	}
+	return false
+}
+
+// controlContainer starts, stops, restarts or kills a container and records
+// the attempt in the events log
+func (r *Router) controlContainer(c *fiber.Ctx) error {
+	action := c.Params("action")
+	if !contains(controlActions, action) {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown action"})
+	}
+
+	var body struct {
+		Timeout *int   `json:"timeout"`
+		Signal  string `json:"signal"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	info, err := r.docker.GetContainer(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Container not found"})
+	}
+
+	if err := r.checkControl(info, action); err != nil {
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	switch action {
+	case "start":
+		err = r.docker.StartContainer(info.ID)
+	case "stop":
+		err = r.docker.StopContainer(info.ID, body.Timeout)
+	case "restart":
+		err = r.docker.RestartContainer(info.ID, body.Timeout)
+	case "kill":
+		err = r.docker.KillContainer(info.ID, body.Signal)
+	}
+
+	r.auditControl(info, action, "the API by "+c.IP(), err)
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("Container %s: %s done", strings.TrimPrefix(info.Name, "/"), action),
+	})
+}
+
+// checkControl checks that an action is enabled and allowed on a container
+func (r *Router) checkControl(info types.ContainerJSON, action string) error {
+	if !contains(r.config.ControlActions, action) {
+		return fmt.Errorf("the %s action is disabled", action)
+	}
+	if info.Config != nil && info.Config.Labels[controlLabel] == "false" {
+		return fmt.Errorf("container does not allow control actions")
+	}
+	return nil
+}
+
+// auditControl records a control action and its outcome in the events log.
+// Outcomes are logged as info or warning so they do not count as container
+// successes and failures in statistics.
+func (r *Router) auditControl(info types.ContainerJSON, action, requestedBy string, actionErr error) {
+	status := "info"
+	message := fmt.Sprintf("%s requested from %s", action, requestedBy)
+	if actionErr != nil {
+		status = "warning"
+		message += ": " + actionErr.Error()
+	}
+
+	r.saveEvent(info.ID, strings.TrimPrefix(info.Name, "/"), "control", status, message, "", time.Now())
+}
@@ -1,69 +1,159 @@
 package api
 
 import (
+	"strings"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/eventlog"
 	"github.com/gofiber/fiber/v2"
-	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
 )
 
-// listEvents returns recent events
+// listEvents returns a page of events matching the query filters
 func (r *Router) listEvents(c *fiber.Ctx) error {
+	return r.queryEvents(c, c.Query("container"))
+}
+
+// getContainerEvents returns a page of events for a specific container
+func (r *Router) getContainerEvents(c *fiber.Ctx) error {
+	return r.queryEvents(c, c.Params("containerId"))
+}
+
+// queryEvents applies pagination and filters to the events log. Supported
+// filters are status, event_type (both comma separated), since, until and q,
+// which searches the message.
+func (r *Router) queryEvents(c *fiber.Ctx, container string) error {
+	page := parsePagination(c)
+	filter := newFilterBuilder()
+
+	filter.oneOf("status", splitList(c.Query("status")))
+	filter.oneOf("event_type", splitList(c.Query("event_type")))
+
+	if container != "" {
+		filter.container(container)
+	}
+
+	if since := c.Query("since"); since != "" {
+		t, err := parseTime(since)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid since: " + err.Error()})
+		}
+		filter.where("timestamp", ">=", t.String())
+	}
+
+	if until := c.Query("until"); until != "" {
+		t, err := parseTime(until)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid until: " + err.Error()})
+		}
+		filter.where("timestamp", "<=", t.String())
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		// Escape wildcards ourselves, PocketBase only does when the value
+		// contains no %
+		filter.where("message", "~", "%"+escapeLike(q)+"%")
+	}
+
+	expr, params := filter.build()
+
+	total, err := r.db.CountRecordsByFilter("events_log", expr, params)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	records, err := r.db.App().Dao().FindRecordsByFilter(
 		"events_log",
-		"",
+		expr,
 		"-timestamp", // Sort by timestamp descending
-		100,          // Limit
-		0,            // Offset
+		page.PerPage,
+		page.offset(),
+		params,
 	)
-	
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	var result []fiber.Map
 	for _, record := range records {
-		result = append(result, fiber.Map{
-			"id":             record.Id,
-			"container_id":   record.GetString("container_id"),
-			"container_name": record.GetString("container_name"),
-			"event_type":     record.GetString("event_type"),
-			"status":         record.GetString("status"),
-			"message":        record.GetString("message"),
-			"timestamp":      record.GetDateTime("timestamp"),
-		})
+		result = append(result, eventJSON(record))
 	}
 
-	return c.JSON(result)
+	return c.JSON(page.response(result, total))
 }
 
-// getContainerEvents returns events for a specific container
-func (r *Router) getContainerEvents(c *fiber.Ctx) error {
-	containerID := c.Params("containerId")
+// eventJSON converts an events_log record to its API representation
+func eventJSON(record *models.Record) fiber.Map {
+	return fiber.Map{
+		"id":                 record.Id,
+		"container_id":       record.GetString("container_id"),
+		"container_name":     record.GetString("container_name"),
+		"event_type":         record.GetString("event_type"),
+		"status":             record.GetString("status"),
+		"message":            record.GetString("message"),
+		"timestamp":          record.GetDateTime("timestamp"),
+		"time_to_running_ms": record.GetInt("time_to_running_ms"),
+		"time_to_healthy_ms": record.GetInt("time_to_healthy_ms"),
+	}
+}
 
-	records, err := r.db.App().Dao().FindRecordsByFilter(
-		"events_log",
-		"container_id = {:containerId}",
-		"-timestamp",
-		100,
-		0,
-		dbx.Params{"containerId": containerID},
-	)
-	
+// pruneEvents applies the retention policy immediately. The body may
+// override the default max_age (e.g. "30d") and max_rows for this run.
+func (r *Router) pruneEvents(c *fiber.Ctx) error {
+	var body struct {
+		MaxAge  string `json:"max_age"`
+		MaxRows *int   `json:"max_rows"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	policy := r.pruner.Policy()
+
+	if body.MaxAge != "" {
+		maxAge, err := config.ParseDuration(body.MaxAge)
+		if err != nil || maxAge <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid max_age"})
+		}
+		policy.Default.MaxAge = maxAge
+	}
+	if body.MaxRows != nil {
+		if *body.MaxRows < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid max_rows"})
+		}
+		policy.Default.MaxRows = *body.MaxRows
+	}
+
+	if !policy.Enabled() {
+		return c.Status(400).JSON(fiber.Map{"error": "No retention policy configured"})
+	}
+
+	result, err := r.pruner.Prune(policy)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	var result []fiber.Map
-	for _, record := range records {
-		result = append(result, fiber.Map{
-			"id":             record.Id,
-			"container_id":   record.GetString("container_id"),
-			"container_name": record.GetString("container_name"),
-			"event_type":     record.GetString("event_type"),
-			"status":         record.GetString("status"),
-			"message":        record.GetString("message"),
-			"timestamp":      record.GetDateTime("timestamp"),
-		})
-	}
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"deleted":  result.Deleted,
+		"archived": result.Archived,
+		"archive":  result.Archive,
+	})
+}
 
-	return c.JSON(result)
+// saveEvent stores an event in the events log and publishes it to live updates
+func (r *Router) saveEvent(containerID, containerName, eventType, status, message, incidentID string, timestamp time.Time) (*models.Record, error) {
+	return eventlog.Log(r.db, r.hub, eventlog.Entry{
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		EventType:     eventType,
+		Status:        status,
+		Message:       message,
+		IncidentID:    incidentID,
+		Timestamp:     timestamp,
+	})
 }
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
 	"github.com/gofiber/fiber/v2"
 	"github.com/pocketbase/pocketbase/models"
 )
@@ -19,7 +22,7 @@ func (r *Router) getSetupStatus(c *fiber.Ctx) error {
 	// Check if we have any notification channels configured
 	records, err := r.db.App().Dao().FindRecordsByFilter(
 		"notifications",
-		"",
+		database.MatchAll,
 		"",
 		1,
 		0,
@@ -47,26 +50,33 @@ func (r *Router) completeSetup(c *fiber.Ctx) error {
 // getStats returns application statistics
 func (r *Router) getStats(c *fiber.Ctx) error {
 	// Count containers
-	containers, err := r.db.App().Dao().FindRecordsByFilter("containers", "", "", 0, 0)
+	containersCount, err := r.db.CountRecordsByFilter("containers", database.MatchAll)
 	if err != nil {
-		containers = []*models.Record{}
+		containersCount = 0
 	}
 
 	// Count notification channels
-	notifications, err := r.db.App().Dao().FindRecordsByFilter("notifications", "", "", 0, 0)
+	notificationsCount, err := r.db.CountRecordsByFilter("notifications", database.MatchAll)
+	if err != nil {
+		notificationsCount = 0
+	}
+
+	// Count all logged events
+	eventsCount, err := r.db.CountRecordsByFilter("events_log", database.MatchAll)
 	if err != nil {
-		notifications = []*models.Record{}
+		eventsCount = 0
 	}
 
-	// Count recent events (last 24 hours)
-	events, err := r.db.App().Dao().FindRecordsByFilter("events_log", "", "", 0, 0)
+	// Summarize the last 24 hours
+	overview, err := r.stats.Overview(time.Now().Add(-24*time.Hour), 5)
 	if err != nil {
-		events = []*models.Record{}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
-		"containers_count":     len(containers),
-		"notifications_count":  len(notifications),
-		"events_count":         len(events),
+		"containers_count":    containersCount,
+		"notifications_count": notificationsCount,
+		"events_count":        eventsCount,
+		"last_24h":            overview,
 	})
 }
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/heartbeats"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// heartbeatRequest is the body for creating or updating a heartbeat
+type heartbeatRequest struct {
+	Name     string    `json:"name"`
+	Interval string    `json:"interval"`
+	Grace    *string   `json:"grace"`
+	Channels *[]string `json:"channels"`
+}
+
+// pingHeartbeat records a ping from a cron job or script
+func (r *Router) pingHeartbeat(c *fiber.Ctx) error {
+	record, err := r.heartbeats.Ping(c.Params("token"))
+	if errors.Is(err, heartbeats.ErrNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Heartbeat " + record.GetString("name") + " received",
+	})
+}
+
+// listHeartbeats returns all heartbeats with their ping URLs and state
+func (r *Router) listHeartbeats(c *fiber.Ctx) error {
+	records, err := r.db.App().Dao().FindRecordsByFilter("heartbeats", database.MatchAll, "name", 0, 0)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	result := make([]fiber.Map, 0, len(records))
+	for _, record := range records {
+		result = append(result, r.heartbeatResponse(c, record))
+	}
+
+	return c.JSON(result)
+}
+
+// createHeartbeat adds a heartbeat with a new ping token
+func (r *Router) createHeartbeat(c *fiber.Ctx) error {
+	var body heartbeatRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || body.Interval == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name and interval are required"})
+	}
+	if err := validateHeartbeat(body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dao := r.db.App().Dao()
+	if _, err := dao.FindFirstRecordByData("heartbeats", "name", body.Name); err == nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A heartbeat with this name already exists"})
+	}
+
+	collection, err := dao.FindCollectionByNameOrId("heartbeats")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	record := models.NewRecord(collection)
+	record.Set("name", body.Name)
+	record.Set("token", security.RandomString(32))
+	record.Set("interval", body.Interval)
+	if body.Grace != nil {
+		record.Set("grace", *body.Grace)
+	}
+	record.Set("status", heartbeats.StatusNew)
+	if body.Channels != nil {
+		record.Set("channels", *body.Channels)
+	}
+
+	if err := dao.SaveRecord(record); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(r.heartbeatResponse(c, record))
+}
+
+// updateHeartbeat changes the schedule or channels of a heartbeat
+func (r *Router) updateHeartbeat(c *fiber.Ctx) error {
+	dao := r.db.App().Dao()
+	record, err := dao.FindRecordById("heartbeats", c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	}
+
+	var body heartbeatRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validateHeartbeat(body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if name := strings.TrimSpace(body.Name); name != "" && name != record.GetString("name") {
+		if _, err := dao.FindFirstRecordByData("heartbeats", "name", name); err == nil {
+			return c.Status(409).JSON(fiber.Map{"error": "A heartbeat with this name already exists"})
+		}
+		record.Set("name", name)
+	}
+	if body.Interval != "" {
+		record.Set("interval", body.Interval)
+	}
+	if body.Grace != nil {
+		record.Set("grace", *body.Grace)
+	}
+	if body.Channels != nil {
+		record.Set("channels", *body.Channels)
+	}
+
+	if err := dao.SaveRecord(record); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Heartbeat updated",
+	})
+}
+
+// deleteHeartbeat removes a heartbeat
+func (r *Router) deleteHeartbeat(c *fiber.Ctx) error {
+	record, err := r.db.App().Dao().FindRecordById("heartbeats", c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	}
+
+	if err := r.db.App().Dao().DeleteRecord(record); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Heartbeat deleted",
+	})
+}
+
+// heartbeatResponse converts a heartbeat record to its API representation.
+// Ping URLs use BASE_URL, or the URL of the request when it is not set.
+func (r *Router) heartbeatResponse(c *fiber.Ctx, record *models.Record) fiber.Map {
+	baseURL := strings.TrimRight(r.config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = c.BaseURL()
+	}
+
+	var channels []string
+	_ = record.UnmarshalJSONField("channels", &channels)
+
+	token := record.GetString("token")
+	heartbeat := fiber.Map{
+		"id":        record.Id,
+		"name":      record.GetString("name"),
+		"token":     token,
+		"ping_url":  baseURL + "/api/heartbeat/" + token,
+		"interval":  record.GetString("interval"),
+		"grace":     record.GetString("grace"),
+		"channels":  channels,
+		"last_ping": record.GetDateTime("last_ping"),
+		"status":    record.GetString("status"),
+	}
+	if due, ok := r.heartbeats.Due(record); ok {
+		heartbeat["due"] = due.UTC()
+	}
+	return heartbeat
+}
+
+// validateHeartbeat checks the durations of a heartbeat request
+func validateHeartbeat(body heartbeatRequest) error {
+	values := []string{body.Interval}
+	if body.Grace != nil {
+		values = append(values, *body.Grace)
+	}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if d, err := config.ParseDuration(value); err != nil || d <= 0 {
+			return errors.New("Invalid duration " + value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"crypto/subtle"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ingestStatuses are the statuses accepted from external sources
+var ingestStatuses = []string{"success", "failure", "warning", "info"}
+
+// ingestRequest is an event sent by CI pipelines, scripts and other tools
+type ingestRequest struct {
+	Source     string               `json:"source"`
+	Event      string               `json:"event"`
+	Status     string               `json:"status"`
+	Severity   string               `json:"severity"`
+	Title      string               `json:"title"`
+	Message    string               `json:"message"`
+	Fields     map[string]string    `json:"fields"`
+	Links      []notifications.Link `json:"links"`
+	Logs       string               `json:"logs"`
+	IncidentID string               `json:"incident_id"`
+	Channels   []string             `json:"channels"`
+	Timestamp  *time.Time           `json:"timestamp"`
+}
+
+// requireIngestToken rejects requests without a configured ingest token,
+// passed as a bearer token or in the X-NotifyPipe-Token header
+func (r *Router) requireIngestToken(c *fiber.Ctx) error {
+	if len(r.config.IngestTokens) == 0 {
+		return c.Status(403).JSON(fiber.Map{"error": "Ingest is disabled, set INGEST_TOKENS to enable it"})
+	}
+	if !validToken(requestToken(c), r.config.IngestTokens) {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid ingest token"})
+	}
+	return c.Next()
+}
+
+// requestToken returns the bearer token or X-NotifyPipe-Token header of a
+// request
+func requestToken(c *fiber.Ctx) string {
+	if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return c.Get("X-NotifyPipe-Token")
+}
+
+// validToken reports whether a token is one of the allowed tokens
+func validToken(token string, allowed []string) bool {
+	for _, t := range allowed {
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ingestEvent stores an external event and routes it to notification channels
+func (r *Router) ingestEvent(c *fiber.Ctx) error {
+	var body ingestRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	body.Source = strings.TrimSpace(body.Source)
+	if body.Source == "" || body.Message == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Source and message are required"})
+	}
+	if body.Event == "" {
+		body.Event = "custom"
+	}
+	if body.Status == "" {
+		body.Status = "info"
+	}
+	if !contains(ingestStatuses, body.Status) {
+		return c.Status(400).JSON(fiber.Map{"error": "Status must be one of " + strings.Join(ingestStatuses, ", ")})
+	}
+
+	event := notifications.NewEvent(body.Event, body.Status, body.Message)
+	if body.Severity != "" {
+		event.Severity = notifications.Severity(body.Severity)
+		if !event.Severity.Valid() {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid severity"})
+		}
+	}
+	if body.Timestamp != nil {
+		event.Timestamp = body.Timestamp.UTC()
+	}
+	event.Title = body.Title
+	event.Links = body.Links
+	event.Logs = body.Logs
+	event.IncidentID = body.IncidentID
+	event.Host = r.hub.Host()
+
+	event.AddField("Source", body.Source, true)
+	names := make([]string, 0, len(body.Fields))
+	for name := range body.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event.AddField(name, body.Fields[name], true)
+	}
+
+	record, err := r.saveEvent("ingest:"+body.Source, body.Source, body.Event, body.Status, body.Message, body.IncidentID, event.Timestamp)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	event.ID = record.Id
+
+	notify := event.Severity.AtLeast(notifications.Severity(r.config.IngestMinSeverity))
+	if notify {
+		channels := r.ingestChannels(body.Source, body.Channels)
+		go func() {
+			if len(channels) == 0 {
+				r.notifier.Notify(event)
+			} else {
+				r.notifier.NotifyChannels(channels, event)
+			}
+		}()
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"success":  true,
+		"id":       record.Id,
+		"notified": notify,
+	})
+}
+
+// ingestChannels returns the channels an event from a source is routed to.
+// Channels named in the request win, then every route whose pattern matches
+// the source. No channels means all enabled channels.
+func (r *Router) ingestChannels(source string, requested []string) []string {
+	if len(requested) > 0 {
+		return requested
+	}
+
+	var channels []string
+	for pattern, routed := range r.config.IngestRoutes {
+		if matched, _ := path.Match(pattern, source); !matched {
+			continue
+		}
+		for _, channel := range routed {
+			if !contains(channels, channel) {
+				channels = append(channels, channel)
+			}
+		}
+	}
+	return channels
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/docker"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// listJobs returns the containers in job mode with their last run
+func (r *Router) listJobs(c *fiber.Ctx) error {
+	records, err := r.db.App().Dao().FindRecordsByFilter("jobs", database.MatchAll, "name", 0, 0)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	result := make([]fiber.Map, 0, len(records))
+	for _, record := range records {
+		job := fiber.Map{
+			"id":               record.Id,
+			"name":             record.GetString("name"),
+			"every":            record.GetString("every"),
+			"grace":            record.GetString("grace"),
+			"last_started":     record.GetDateTime("last_started"),
+			"last_finished":    record.GetDateTime("last_finished"),
+			"last_status":      record.GetString("last_status"),
+			"last_duration_ms": record.GetInt("last_duration_ms"),
+			"last_exit_code":   record.GetInt("last_exit_code"),
+			"overdue":          record.GetBool("overdue"),
+		}
+		if due, ok := docker.JobDue(record, r.config.JobGrace); ok {
+			job["due"] = due.UTC()
+		}
+		result = append(result, job)
+	}
+
+	return c.JSON(result)
+}
+
+// updateJob puts containers with a name in job mode and sets the schedule
+// they are expected to run on
+func (r *Router) updateJob(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var body struct {
+		Every string `json:"every"`
+		Grace string `json:"grace"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	for _, value := range []string{body.Every, body.Grace} {
+		if value == "" {
+			continue
+		}
+		if d, err := config.ParseDuration(value); err != nil || d <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid duration " + value})
+		}
+	}
+
+	dao := r.db.App().Dao()
+	record, err := dao.FindFirstRecordByData("jobs", "name", name)
+	if err != nil {
+		collection, err := dao.FindCollectionByNameOrId("jobs")
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		record = models.NewRecord(collection)
+		record.Set("name", name)
+	}
+
+	record.Set("every", body.Every)
+	record.Set("grace", body.Grace)
+	record.Set("overdue", false)
+	if err := dao.SaveRecord(record); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Job updated",
+	})
+}
+
+// deleteJob takes containers with a name out of job mode
+func (r *Router) deleteJob(c *fiber.Ctx) error {
+	record, err := r.db.App().Dao().FindFirstRecordByData("jobs", "name", c.Params("name"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+
+	if err := r.db.App().Dao().DeleteRecord(record); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Job deleted",
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/fatlirmorina/notifypipe/internal/docker"
+	"github.com/fatlirmorina/notifypipe/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler serves application metrics in the Prometheus text format
+func MetricsHandler(dockerClient *docker.Client) fiber.Handler {
+	// Container states are read from Docker at scrape time
+	metrics.SetContainerStates(func() ([]string, error) {
+		containers, err := dockerClient.ListContainers()
+		if err != nil {
+			return nil, err
+		}
+
+		states := make([]string, 0, len(containers))
+		for _, container := range containers {
+			states = append(states, container.State)
+		}
+		return states, nil
+	})
+
+	return adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	}))
+}
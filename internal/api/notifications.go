@@ -1,15 +1,23 @@
 package api
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
 	"github.com/gofiber/fiber/v2"
 	"github.com/pocketbase/pocketbase/models"
 )
 
+// maskedSecret replaces webhook secrets in responses
+const maskedSecret = "********"
+
 // listNotifications returns all notification channels
 func (r *Router) listNotifications(c *fiber.Ctx) error {
 	records, err := r.db.App().Dao().FindRecordsByFilter(
 		"notifications",
-		"",
+		database.MatchAll,
 		"",
 		0,
 		0,
@@ -20,13 +28,41 @@ func (r *Router) listNotifications(c *fiber.Ctx) error {
 
 	var result []fiber.Map
 	for _, record := range records {
-		result = append(result, fiber.Map{
-			"id":      record.Id,
-			"name":    record.GetString("name"),
-			"type":    record.GetString("type"),
-			"url":     record.GetString("url"),
-			"enabled": record.GetBool("enabled"),
-		})
+		channel := fiber.Map{
+			"id":                   record.Id,
+			"name":                 record.GetString("name"),
+			"type":                 record.GetString("type"),
+			"url":                  record.GetString("url"),
+			"enabled":              record.GetBool("enabled"),
+			"health":               notifications.ChannelHealth(record),
+			"consecutive_failures": record.GetInt("consecutive_failures"),
+			"fallback":             record.GetString("fallback"),
+			"reroute":              record.GetBool("reroute"),
+		}
+		if lastError := record.GetString("last_error"); lastError != "" {
+			channel["last_error"] = lastError
+		}
+		if lastTested := record.GetDateTime("last_tested"); !lastTested.IsZero() {
+			channel["last_tested"] = lastTested
+			channel["last_test_success"] = record.GetBool("last_test_success")
+			if testError := record.GetString("last_test_error"); testError != "" {
+				channel["last_test_error"] = testError
+			}
+		}
+		if timezone := record.GetString("timezone"); timezone != "" {
+			channel["timezone"] = timezone
+		}
+		if quiet, _ := notifications.ChannelQuietHours(record); quiet != nil {
+			channel["quiet_hours"] = quiet
+		}
+		if record.GetString("type") == notifications.TypeWebhook {
+			config, _ := notifications.ChannelWebhookConfig(record)
+			if config.Secret != "" {
+				config.Secret = maskedSecret
+			}
+			channel["config"] = config
+		}
+		result = append(result, channel)
 	}
 
 	return c.JSON(result)
@@ -35,17 +71,28 @@ func (r *Router) listNotifications(c *fiber.Ctx) error {
 // createNotification creates a new notification channel
 func (r *Router) createNotification(c *fiber.Ctx) error {
 	var body struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
-		URL  string `json:"url"`
+		Name       string                       `json:"name"`
+		Type       string                       `json:"type"`
+		URL        string                       `json:"url"`
+		Config     *notifications.WebhookConfig `json:"config"`
+		Timezone   string                       `json:"timezone"`
+		QuietHours *notifications.QuietHours    `json:"quiet_hours"`
+		Fallback   string                       `json:"fallback"`
+		Reroute    bool                         `json:"reroute"`
 	}
 
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	if body.Name == "" || body.Type == "" || body.URL == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Name, type, and URL are required"})
+	if errs := channelErrors(body.Name, body.Type, body.URL, body.Config); errs != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid notification channel", "fields": errs})
+	}
+	if err := validateQuietHours(body.Timezone, body.QuietHours); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := r.validateFallback("", body.Fallback); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	collection, err := r.db.App().Dao().FindCollectionByNameOrId("notifications")
@@ -58,6 +105,15 @@ func (r *Router) createNotification(c *fiber.Ctx) error {
 	record.Set("type", body.Type)
 	record.Set("url", body.URL)
 	record.Set("enabled", true)
+	if body.Config != nil {
+		record.Set("config", body.Config)
+	}
+	record.Set("timezone", body.Timezone)
+	if body.QuietHours != nil && body.QuietHours.Start != "" {
+		record.Set("quiet_hours", body.QuietHours)
+	}
+	record.Set("fallback", body.Fallback)
+	record.Set("reroute", body.Reroute)
 
 	if err := r.db.App().Dao().SaveRecord(record); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -75,10 +131,15 @@ func (r *Router) updateNotification(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	var body struct {
-		Name    string `json:"name"`
-		Type    string `json:"type"`
-		URL     string `json:"url"`
-		Enabled bool   `json:"enabled"`
+		Name       string                       `json:"name"`
+		Type       string                       `json:"type"`
+		URL        string                       `json:"url"`
+		Enabled    bool                         `json:"enabled"`
+		Config     *notifications.WebhookConfig `json:"config"`
+		Timezone   *string                      `json:"timezone"`
+		QuietHours *notifications.QuietHours    `json:"quiet_hours"`
+		Fallback   *string                      `json:"fallback"`
+		Reroute    *bool                        `json:"reroute"`
 	}
 
 	if err := c.BodyParser(&body); err != nil {
@@ -99,8 +160,58 @@ func (r *Router) updateNotification(c *fiber.Ctx) error {
 	if body.URL != "" {
 		record.Set("url", body.URL)
 	}
+	if body.Config != nil {
+		// The masked secret from listNotifications keeps the stored one
+		if body.Config.Secret == maskedSecret {
+			current, _ := notifications.ChannelWebhookConfig(record)
+			body.Config.Secret = current.Secret
+		}
+		record.Set("config", body.Config)
+	}
 	record.Set("enabled", body.Enabled)
 
+	timezone := record.GetString("timezone")
+	if body.Timezone != nil {
+		timezone = *body.Timezone
+	}
+	if err := validateQuietHours(timezone, body.QuietHours); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	record.Set("timezone", timezone)
+	if body.QuietHours != nil {
+		// Quiet hours without a start turn them off
+		if body.QuietHours.Start == "" {
+			record.Set("quiet_hours", nil)
+		} else {
+			record.Set("quiet_hours", body.QuietHours)
+		}
+	}
+	if body.Fallback != nil {
+		if err := r.validateFallback(record.Id, *body.Fallback); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		record.Set("fallback", *body.Fallback)
+	}
+	if body.Reroute != nil {
+		record.Set("reroute", *body.Reroute)
+	}
+
+	// Channels saved before URLs were validated must stay editable, so only
+	// changed settings are checked
+	if body.Type != "" || body.URL != "" || body.Config != nil {
+		var config *notifications.WebhookConfig
+		if record.GetString("type") == notifications.TypeWebhook {
+			current, err := notifications.ChannelWebhookConfig(record)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			config = &current
+		}
+		if errs := channelErrors(record.GetString("name"), record.GetString("type"), record.GetString("url"), config); errs != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid notification channel", "fields": errs})
+		}
+	}
+
 	if err := r.db.App().Dao().SaveRecord(record); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -133,18 +244,85 @@ func (r *Router) deleteNotification(c *fiber.Ctx) error {
 // testNotification tests a notification channel
 func (r *Router) testNotification(c *fiber.Ctx) error {
 	var body struct {
-		URL string `json:"url"`
+		Type   string                       `json:"type"`
+		URL    string                       `json:"url"`
+		Config *notifications.WebhookConfig `json:"config"`
 	}
 
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	if body.URL == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "URL is required"})
+	if body.Type == "" {
+		body.Type = notifications.TypeForURL(body.URL)
+	}
+	if errs := settingsErrors(body.Type, body.URL, body.Config); errs != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid notification channel", "fields": errs})
+	}
+
+	var err error
+	if body.Type == notifications.TypeWebhook {
+		config := notifications.WebhookConfig{}
+		if body.Config != nil {
+			config = *body.Config
+		}
+		err = r.notifier.TestWebhook(body.URL, config)
+	} else {
+		err = r.notifier.TestNotification(body.URL)
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Test notification sent successfully",
+	})
+}
+
+// testSavedNotification sends a test message through a saved channel, or
+// only validates its configuration on a dry run
+func (r *Router) testSavedNotification(c *fiber.Ctx) error {
+	record, err := r.db.App().Dao().FindRecordById("notifications", c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Notification not found"})
+	}
+
+	var body struct {
+		Message string `json:"message"`
+		DryRun  bool   `json:"dry_run"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
 	}
 
-	if err := r.notifier.TestNotification(body.URL); err != nil {
+	var config *notifications.WebhookConfig
+	if record.GetString("type") == notifications.TypeWebhook {
+		current, err := notifications.ChannelWebhookConfig(record)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		config = &current
+	}
+	if errs := channelErrors(record.GetString("name"), record.GetString("type"), record.GetString("url"), config); errs != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid notification channel", "fields": errs})
+	}
+
+	if body.DryRun {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"dry_run": true,
+			"message": "Notification channel configuration is valid",
+		})
+	}
+
+	if err := r.notifier.TestChannel(record, body.Message); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
@@ -156,3 +334,70 @@ func (r *Router) testNotification(c *fiber.Ctx) error {
 		"message": "Test notification sent successfully",
 	})
 }
+
+// listServices returns the supported channel types with the fields of their
+// URLs
+func (r *Router) listServices(c *fiber.Ctx) error {
+	return c.JSON(notifications.Services())
+}
+
+// channelErrors returns the problems with a channel's settings per field, or
+// nil if there are none
+func channelErrors(name, channelType, rawURL string, config *notifications.WebhookConfig) map[string]string {
+	errs := settingsErrors(channelType, rawURL, config)
+	if strings.TrimSpace(name) == "" {
+		if errs == nil {
+			errs = make(map[string]string)
+		}
+		errs["name"] = "Name is required"
+	}
+	return errs
+}
+
+// settingsErrors returns the problems with the type, URL and webhook config
+// of a channel per field, or nil if there are none
+func settingsErrors(channelType, rawURL string, config *notifications.WebhookConfig) map[string]string {
+	errs := notifications.ValidateChannel(channelType, rawURL)
+	if channelType == notifications.TypeWebhook && config != nil {
+		if err := config.Validate(); err != nil {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			errs["config"] = err.Error()
+		}
+	}
+	return errs
+}
+
+// validateQuietHours checks the timezone and quiet hours of a channel
+func validateQuietHours(timezone string, quiet *notifications.QuietHours) error {
+	if timezone != "" {
+		if err := notifications.ValidTimezone(timezone); err != nil {
+			return err
+		}
+	}
+	if quiet != nil && quiet.Start != "" {
+		return quiet.Validate()
+	}
+	return nil
+}
+
+// validateFallback checks that a fallback channel exists and is not the
+// channel itself
+func (r *Router) validateFallback(id, fallback string) error {
+	if fallback == "" {
+		return nil
+	}
+
+	record, err := r.db.App().Dao().FindRecordById("notifications", fallback)
+	if err != nil {
+		record, err = r.db.App().Dao().FindFirstRecordByData("notifications", "name", fallback)
+		if err != nil {
+			return fmt.Errorf("fallback channel %q not found", fallback)
+		}
+	}
+	if record.Id == id {
+		return fmt.Errorf("a channel cannot be its own fallback")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	defaultPerPage = 100
+	maxPerPage     = 500
+)
+
+// containerIDPattern matches full or abbreviated container IDs
+var containerIDPattern = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+// pagination holds the page requested by the client
+type pagination struct {
+	Page    int
+	PerPage int
+}
+
+// parsePagination reads the page and per_page query parameters
+func parsePagination(c *fiber.Ctx) pagination {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	perPage := c.QueryInt("per_page", defaultPerPage)
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	return pagination{Page: page, PerPage: perPage}
+}
+
+// offset returns the number of items skipped before the current page
+func (p pagination) offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// response wraps a page of items with the pagination totals
+func (p pagination) response(items []fiber.Map, total int) fiber.Map {
+	if items == nil {
+		items = []fiber.Map{}
+	}
+
+	return fiber.Map{
+		"items":       items,
+		"page":        p.Page,
+		"per_page":    p.PerPage,
+		"total_items": total,
+		"total_pages": (total + p.PerPage - 1) / p.PerPage,
+	}
+}
+
+// filterBuilder assembles a PocketBase filter expression. Field names come
+// from code only; user supplied values are always bound as parameters.
+type filterBuilder struct {
+	clauses []string
+	params  dbx.Params
+}
+
+// newFilterBuilder creates an empty filter builder
+func newFilterBuilder() *filterBuilder {
+	return &filterBuilder{params: dbx.Params{}}
+}
+
+// bind registers a value and returns its placeholder
+func (f *filterBuilder) bind(value any) string {
+	name := fmt.Sprintf("p%d", len(f.params))
+	f.params[name] = value
+	return "{:" + name + "}"
+}
+
+// where adds a single comparison clause
+func (f *filterBuilder) where(field, operator string, value any) {
+	f.clauses = append(f.clauses, fmt.Sprintf("%s %s %s", field, operator, f.bind(value)))
+}
+
+// oneOf adds a clause matching any of the given values
+func (f *filterBuilder) oneOf(field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	var parts []string
+	for _, value := range values {
+		parts = append(parts, fmt.Sprintf("%s = %s", field, f.bind(value)))
+	}
+	f.clauses = append(f.clauses, "("+strings.Join(parts, " || ")+")")
+}
+
+// container adds a clause matching a container name or ID prefix
+func (f *filterBuilder) container(value string) {
+	clause := fmt.Sprintf("container_name = %s", f.bind(value))
+	if containerIDPattern.MatchString(value) {
+		clause = fmt.Sprintf("(%s || container_id ~ %s)", clause, f.bind(value+"%"))
+	}
+	f.clauses = append(f.clauses, clause)
+}
+
+// build returns the filter expression and its parameters
+func (f *filterBuilder) build() (string, dbx.Params) {
+	if len(f.clauses) == 0 {
+		return database.MatchAll, f.params
+	}
+	return strings.Join(f.clauses, " && "), f.params
+}
+
+// parseTime parses an RFC 3339 timestamp, a YYYY-MM-DD date, or a duration
+// such as "24h" that is interpreted as relative to now
+func parseTime(value string) (types.DateTime, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return types.ParseDateTime(t)
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return types.ParseDateTime(t)
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return types.ParseDateTime(time.Now().Add(-d))
+	}
+	return types.DateTime{}, fmt.Errorf("invalid time %q", value)
+}
+
+// splitList splits a comma separated query value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// contains reports whether value is in values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike escapes the LIKE wildcards in a value matched with ~
+func escapeLike(value string) string {
+	for i := 0; i < len(dbx.DefaultLikeEscape); i += 2 {
+		value = strings.ReplaceAll(value, dbx.DefaultLikeEscape[i], dbx.DefaultLikeEscape[i+1])
+	}
+	return value
+}
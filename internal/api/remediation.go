@@ -0,0 +1,47 @@
+package api
+
+import (
+	"github.com/fatlirmorina/notifypipe/internal/docker"
+	"github.com/gofiber/fiber/v2"
+)
+
+// getRemediation returns the restart rules and whether they are applied
+func (r *Router) getRemediation(c *fiber.Ctx) error {
+	rules := make([]fiber.Map, 0, len(r.config.RestartRules))
+	for _, rule := range r.config.RestartRules {
+		rules = append(rules, fiber.Map{
+			"pattern":      rule.Pattern,
+			"max_attempts": rule.MaxAttempts,
+			"window":       rule.Window.String(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled": docker.AutoRestartEnabled(r.db),
+		"rules":   rules,
+	})
+}
+
+// updateRemediation pauses or resumes every restart rule
+func (r *Router) updateRemediation(c *fiber.Ctx) error {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Enabled == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "enabled is required"})
+	}
+
+	if err := docker.SetAutoRestart(r.db, *body.Enabled); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	message := "Auto-restart resumed"
+	if !*body.Enabled {
+		message = "Auto-restart paused"
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/fatlirmorina/notifypipe/internal/reports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportWindow reads the report window from since/until or month=YYYY-MM,
+// defaulting to the last 30 days
+func reportWindow(sinceValue, untilValue, month string) (time.Time, time.Time, error) {
+	if month != "" {
+		since, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(400, "Invalid month, expected YYYY-MM")
+		}
+		return since, since.AddDate(0, 1, 0), nil
+	}
+
+	until := time.Now()
+	if untilValue != "" {
+		t, err := parseTime(untilValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(400, "Invalid until: "+err.Error())
+		}
+		until = t.Time()
+	}
+
+	since := until.AddDate(0, 0, -30)
+	if sinceValue != "" {
+		t, err := parseTime(sinceValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(400, "Invalid since: "+err.Error())
+		}
+		since = t.Time()
+	}
+
+	if !until.After(since) {
+		return time.Time{}, time.Time{}, fiber.NewError(400, "until must be after since")
+	}
+
+	return since, until, nil
+}
+
+// getUptimeReport returns an uptime report as JSON, CSV, Markdown or HTML
+func (r *Router) getUptimeReport(c *fiber.Ctx) error {
+	since, until, err := reportWindow(c.Query("since"), c.Query("until"), c.Query("month"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := reports.Uptime(r.db, since, until, c.Query("container"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	switch format := c.Query("format", reports.FormatJSON); format {
+	case reports.FormatJSON:
+		return c.JSON(report)
+	case reports.FormatCSV:
+		data, err := report.CSV()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="uptime.csv"`)
+		return c.Send(data)
+	case reports.FormatMarkdown:
+		c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+		return c.SendString(report.Markdown())
+	case reports.FormatHTML:
+		html, err := report.HTML()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(html)
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Unsupported format " + format})
+	}
+}
+
+// sendUptimeReport sends an uptime report through a notification channel
+func (r *Router) sendUptimeReport(c *fiber.Ctx) error {
+	var body struct {
+		Channel   string `json:"channel"`
+		Since     string `json:"since"`
+		Until     string `json:"until"`
+		Month     string `json:"month"`
+		Container string `json:"container"`
+		Format    string `json:"format"`
+	}
+
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if body.Channel == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Channel is required"})
+	}
+
+	since, until, err := reportWindow(body.Since, body.Until, body.Month)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := reports.Uptime(r.db, since, until, body.Container)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	message, err := report.Render(body.Format)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := r.notifier.SendToChannel(body.Channel, notifications.NewEvent("report", "", message)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Uptime report sent",
+	})
+}
@@ -1,20 +1,34 @@
 package api
 
 import (
+	"sync"
+
 	"github.com/fatlirmorina/notifypipe/internal/config"
 	"github.com/fatlirmorina/notifypipe/internal/database"
 	"github.com/fatlirmorina/notifypipe/internal/docker"
+	"github.com/fatlirmorina/notifypipe/internal/heartbeats"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
 	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/fatlirmorina/notifypipe/internal/retention"
+	"github.com/fatlirmorina/notifypipe/internal/stats"
 	"github.com/gofiber/fiber/v2"
 )
 
 // Router handles API routing
 type Router struct {
-	app      *fiber.App
-	db       *database.Database
-	docker   *docker.Client
-	notifier *notifications.Manager
-	config   *config.Config
+	app        *fiber.App
+	db         *database.Database
+	docker     *docker.Client
+	monitor    *docker.EventMonitor
+	notifier   *notifications.Manager
+	hub        *hub.Hub
+	pruner     *retention.Pruner
+	heartbeats *heartbeats.Scheduler
+	stats      *stats.Service
+	config     *config.Config
+
+	// actionsMu serializes single-use action links
+	actionsMu sync.Mutex
 }
 
 // NewRouter creates a new API router
@@ -22,15 +36,24 @@ func NewRouter(
 	app *fiber.App,
 	db *database.Database,
 	dockerClient *docker.Client,
+	monitor *docker.EventMonitor,
 	notifier *notifications.Manager,
+	eventHub *hub.Hub,
+	pruner *retention.Pruner,
+	heartbeatScheduler *heartbeats.Scheduler,
 	cfg *config.Config,
 ) *Router {
 	return &Router{
-		app:      app,
-		db:       db,
-		docker:   dockerClient,
-		notifier: notifier,
-		config:   cfg,
+		app:        app,
+		db:         db,
+		docker:     dockerClient,
+		monitor:    monitor,
+		notifier:   notifier,
+		hub:        eventHub,
+		pruner:     pruner,
+		heartbeats: heartbeatScheduler,
+		stats:      stats.NewService(db),
+		config:     cfg,
 	}
 }
 
@@ -49,18 +72,60 @@ func (r *Router) Setup() {
 	api.Get("/containers", r.listContainers)
 	api.Get("/containers/:id", r.getContainer)
 	api.Put("/containers/:id", r.updateContainer)
+	api.Post("/containers/:id/:action", r.requireControlToken, r.controlContainer)
+
+	// Job mode
+	api.Get("/jobs", r.listJobs)
+	api.Put("/jobs/:name", r.updateJob)
+	api.Delete("/jobs/:name", r.deleteJob)
+
+	// Heartbeats
+	api.Post("/heartbeat/:token", r.pingHeartbeat)
+	api.Get("/heartbeats", r.listHeartbeats)
+	api.Post("/heartbeats", r.createHeartbeat)
+	api.Put("/heartbeats/:id", r.updateHeartbeat)
+	api.Delete("/heartbeats/:id", r.deleteHeartbeat)
+
+	// Automatic restarts
+	api.Get("/remediation", r.getRemediation)
+	api.Put("/remediation", r.requireControlToken, r.updateRemediation)
 
 	// Notifications
 	api.Get("/notifications", r.listNotifications)
+	api.Get("/notifications/services", r.listServices)
 	api.Post("/notifications", r.createNotification)
 	api.Put("/notifications/:id", r.updateNotification)
 	api.Delete("/notifications/:id", r.deleteNotification)
 	api.Post("/notifications/test", r.testNotification)
+	api.Post("/notifications/:id/test", r.testSavedNotification)
 
 	// Events
 	api.Get("/events", r.listEvents)
 	api.Get("/events/:containerId", r.getContainerEvents)
+	api.Post("/events/prune", r.pruneEvents)
+
+	// Events from external sources
+	api.Post("/ingest", r.requireIngestToken, r.ingestEvent)
+
+	// Signed actions from notifications
+	api.Get("/actions/:action", r.showAction)
+	api.Post("/actions/:action", r.performAction)
+
+	// Live updates
+	api.Get("/stream", r.streamEvents)
 
 	// Statistics
 	api.Get("/stats", r.getStats)
+	api.Get("/stats/rollouts", r.getRolloutStats)
+	api.Get("/stats/timeseries", r.getTimeSeries)
+	api.Get("/stats/containers/:id", r.getContainerStats)
+
+	// Reports
+	api.Get("/reports/uptime", r.getUptimeReport)
+	api.Post("/reports/uptime/send", r.sendUptimeReport)
+
+	// Prometheus metrics, unless served on a separate port
+	if r.config.MetricsPort == "" {
+		r.app.Get("/metrics", MetricsHandler(r.docker))
+	}
 }
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/stats"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// rolloutSample is a single timing row read from events_log
+type rolloutSample struct {
+	ContainerName   string  `db:"container_name"`
+	TimeToRunningMs float64 `db:"time_to_running_ms"`
+	TimeToHealthyMs float64 `db:"time_to_healthy_ms"`
+}
+
+// getRolloutStats returns per-container rollout duration percentiles
+func (r *Router) getRolloutStats(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "days must be positive"})
+	}
+
+	since, err := types.ParseDateTime(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	where := dbx.And(
+		dbx.NewExp("timestamp >= {:since}", dbx.Params{"since": since.String()}),
+		dbx.NewExp("(time_to_running_ms > 0 OR time_to_healthy_ms > 0)"),
+	)
+	if name := c.Query("container"); name != "" {
+		where = dbx.And(where, dbx.HashExp{"container_name": name})
+	}
+
+	var samples []rolloutSample
+	err = r.db.App().Dao().DB().
+		Select("container_name", "time_to_running_ms", "time_to_healthy_ms").
+		From("events_log").
+		Where(where).
+		All(&samples)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	running := make(map[string][]float64)
+	healthy := make(map[string][]float64)
+	var names []string
+	for _, sample := range samples {
+		if _, seen := running[sample.ContainerName]; !seen {
+			running[sample.ContainerName] = nil
+			names = append(names, sample.ContainerName)
+		}
+		// The healthy event repeats the running time, so only count it once
+		if sample.TimeToHealthyMs > 0 {
+			healthy[sample.ContainerName] = append(healthy[sample.ContainerName], sample.TimeToHealthyMs)
+		} else if sample.TimeToRunningMs > 0 {
+			running[sample.ContainerName] = append(running[sample.ContainerName], sample.TimeToRunningMs)
+		}
+	}
+	sort.Strings(names)
+
+	result := []fiber.Map{}
+	for _, name := range names {
+		result = append(result, fiber.Map{
+			"container_name":     name,
+			"time_to_running_ms": summarize(running[name]),
+			"time_to_healthy_ms": summarize(healthy[name]),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"days":       days,
+		"containers": result,
+	})
+}
+
+// getTimeSeries returns event and notification counts per hour or day
+func (r *Router) getTimeSeries(c *fiber.Ctx) error {
+	interval := c.Query("interval", stats.IntervalHour)
+
+	until := time.Now()
+	if value := c.Query("until"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid until: " + err.Error()})
+		}
+		until = t.Time()
+	}
+
+	since := until.Add(-24 * time.Hour)
+	if interval == stats.IntervalDay {
+		since = until.AddDate(0, 0, -30)
+	}
+	if value := c.Query("since"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid since: " + err.Error()})
+		}
+		since = t.Time()
+	}
+
+	buckets, err := r.stats.TimeSeries(interval, since, until, c.Query("container"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"interval": interval,
+		"since":    since,
+		"until":    until,
+		"buckets":  buckets,
+	})
+}
+
+// getContainerStats returns reliability statistics for a container
+func (r *Router) getContainerStats(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "days must be positive"})
+	}
+
+	result, err := r.stats.Container(c.Params("id"), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(result)
+}
+
+// summarize computes count and percentiles for a set of samples
+func summarize(values []float64) fiber.Map {
+	if len(values) == 0 {
+		return fiber.Map{"count": 0}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	return fiber.Map{
+		"count": len(sorted),
+		"min":   sorted[0],
+		"p50":   percentile(sorted, 50),
+		"p90":   percentile(sorted, 90),
+		"p95":   percentile(sorted, 95),
+		"p99":   percentile(sorted, 99),
+		"max":   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
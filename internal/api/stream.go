@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/hub"
+	"github.com/gofiber/fiber/v2"
+)
+
+// heartbeatInterval is how often idle streams receive a heartbeat event
+const heartbeatInterval = 15 * time.Second
+
+// streamEvents pushes live updates to the client using Server-Sent Events
+func (r *Router) streamEvents(c *fiber.Ctx) error {
+	filter := hub.Filter{
+		Containers: splitList(c.Query("container")),
+		Hosts:      splitList(c.Query("host")),
+		Types:      splitList(c.Query("types")),
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	sub := r.hub.Subscribe(filter)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer r.hub.Unsubscribe(sub)
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case msg, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(msg)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, data)
+			case now := <-ticker.C:
+				fmt.Fprintf(w, "event: heartbeat\ndata: {\"time\":%q}\n\n", now.UTC().Format(time.RFC3339))
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
@@ -11,16 +15,155 @@ type Config struct {
 	DockerSocket string
 	DataDir      string
 	LogLevel     string
+	MetricsPort  string
+
+	// Event retention
+	RetentionMaxAge        time.Duration
+	RetentionMaxRows       int
+	RetentionStatusMaxAge  map[string]time.Duration
+	RetentionStatusMaxRows map[string]int
+	RetentionInterval      time.Duration
+	RetentionArchiveDir    string
+
+	// Scheduled uptime reports
+	ReportSchedule string
+	ReportChannels []string
+	ReportFormat   string
+
+	// Inbound events
+	IngestTokens      []string
+	IngestRoutes      map[string][]string
+	IngestMinSeverity string
+
+	// Notification channel health and failover
+	ChannelDegradedAfter int
+	ChannelFailingAfter  int
+	FallbackChannel      string
+
+	// Signed action links in notifications
+	ActionSecret string
+	ActionTTL    time.Duration
+
+	// Container control actions allowed through the API, and the tokens
+	// required to use them
+	ControlActions []string
+	ControlTokens  []string
+
+	// Automatic restarts of failed containers
+	RestartRules []RestartRule
+
+	// Alerts on container log lines
+	LogAlertContainers []string
+	LogAlertPatterns   []string
+	LogAlertContext    int
+	LogAlertCooldown   time.Duration
+
+	// Resource usage alerts
+	ResourceInterval        time.Duration
+	ResourceCPUThreshold    float64
+	ResourceMemoryThreshold float64
+	ResourceThresholdFor    time.Duration
+
+	// How often container records are reconciled with Docker
+	ReconcileInterval time.Duration
+
+	// Lifecycle actions notified for containers without their own choice
+	NotifyActions []string
+
+	// What exit codes mean for the containers a selector matches
+	ExitPolicies []ExitPolicy
+
+	// How late a scheduled job may start before it is reported overdue
+	JobGrace time.Duration
+
+	// How late a heartbeat ping may arrive before it is reported late
+	HeartbeatGrace time.Duration
+
+	// Defaults for endpoint probes of running containers, and the host their
+	// published ports are reached on
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+	ProbeFailures int
+	ProbeHost     string
+}
+
+// ExitPolicy applies a policy such as "success:0-2 ignore:137" to containers
+// whose name matches Selector, or with a "label:key=value" selector to
+// containers with that label
+type ExitPolicy struct {
+	Selector string
+	Policy   string
+}
+
+// RestartRule restarts containers whose name matches Pattern after a failure,
+// at most MaxAttempts times within Window
+type RestartRule struct {
+	Pattern     string
+	MaxAttempts int
+	Window      time.Duration
 }
 
 // Load loads the configuration from environment variables
 func Load() *Config {
 	return &Config{
 		Port:         getEnv("PORT", "8080"),
-		BaseURL:      getEnv("BASE_URL", "http://localhost:8080"),
+		BaseURL:      getEnv("BASE_URL", ""),
 		DockerSocket: getEnv("DOCKER_SOCKET", "/var/run/docker.sock"),
 		DataDir:      getEnv("DATA_DIR", "./data"),
 		LogLevel:     getEnv("LOG_LEVEL", "info"),
+		MetricsPort:  getEnv("METRICS_PORT", ""),
+
+		RetentionMaxAge:        getEnvDuration("RETENTION_MAX_AGE", 0),
+		RetentionMaxRows:       getEnvInt("RETENTION_MAX_ROWS", 0),
+		RetentionStatusMaxAge:  getEnvDurationMap("RETENTION_STATUS_MAX_AGE"),
+		RetentionStatusMaxRows: getEnvIntMap("RETENTION_STATUS_MAX_ROWS"),
+		RetentionInterval:      getEnvDuration("RETENTION_INTERVAL", time.Hour),
+		RetentionArchiveDir:    getEnv("RETENTION_ARCHIVE_DIR", ""),
+
+		ReportSchedule: getEnv("REPORT_SCHEDULE", ""),
+		ReportChannels: getEnvList("REPORT_CHANNELS", ""),
+		ReportFormat:   getEnv("REPORT_FORMAT", "markdown"),
+
+		IngestTokens:      getEnvList("INGEST_TOKENS", ""),
+		IngestRoutes:      getEnvRoutes("INGEST_ROUTES"),
+		IngestMinSeverity: getEnvChoice("INGEST_MIN_SEVERITY", "info", "info", "success", "warning", "error"),
+
+		ChannelDegradedAfter: getEnvInt("CHANNEL_DEGRADED_AFTER", 1),
+		ChannelFailingAfter:  getEnvInt("CHANNEL_FAILING_AFTER", 3),
+		FallbackChannel:      getEnv("FALLBACK_CHANNEL", ""),
+
+		ActionSecret: getEnv("ACTION_SECRET", ""),
+		ActionTTL:    getEnvDuration("ACTION_TTL", 24*time.Hour),
+
+		ControlActions: getEnvList("CONTROL_ACTIONS", ""),
+		ControlTokens:  getEnvList("CONTROL_TOKENS", ""),
+
+		RestartRules: getEnvRestartRules("RESTART_RULES"),
+
+		LogAlertContainers: getEnvList("LOG_ALERT_CONTAINERS", ""),
+		LogAlertPatterns:   getEnvList("LOG_ALERT_PATTERNS", "FATAL,panic:"),
+		LogAlertContext:    getEnvInt("LOG_ALERT_CONTEXT", 5),
+		LogAlertCooldown:   getEnvDuration("LOG_ALERT_COOLDOWN", 5*time.Minute),
+
+		ResourceInterval:        getEnvDuration("RESOURCE_INTERVAL", 30*time.Second),
+		ResourceCPUThreshold:    getEnvFloat("RESOURCE_CPU_THRESHOLD", 90),
+		ResourceMemoryThreshold: getEnvFloat("RESOURCE_MEMORY_THRESHOLD", 90),
+		ResourceThresholdFor:    getEnvDuration("RESOURCE_THRESHOLD_FOR", 5*time.Minute),
+
+		ReconcileInterval: getEnvDuration("RECONCILE_INTERVAL", 5*time.Minute),
+
+		NotifyActions: getEnvList("NOTIFY_ACTIONS", ""),
+
+		ExitPolicies: getEnvExitPolicies("EXIT_POLICIES"),
+
+		JobGrace: getEnvDuration("JOB_GRACE", 15*time.Minute),
+
+		HeartbeatGrace: getEnvDuration("HEARTBEAT_GRACE", 5*time.Minute),
+
+		ProbeInterval: getEnvDuration("PROBE_INTERVAL", 30*time.Second),
+		ProbeTimeout:  getEnvDuration("PROBE_TIMEOUT", 5*time.Second),
+		ProbeFailures: getEnvInt("PROBE_FAILURES", 3),
+		ProbeHost:     getEnv("PROBE_HOST", "localhost"),
 	}
 }
 
@@ -32,3 +175,190 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt gets an integer environment variable with a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %v, using default", key, err)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvFloat gets a decimal environment variable with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s: %v, using default", key, err)
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvDuration gets a duration environment variable with a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %v, using default", key, err)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvChoice gets an environment variable that must be one of the given
+// choices, with a default value
+func getEnvChoice(key, defaultValue string, choices ...string) string {
+	value := getEnv(key, defaultValue)
+	for _, choice := range choices {
+		if value == choice {
+			return value
+		}
+	}
+
+	log.Printf("Invalid value for %s: %q is not one of %s, using default", key, value, strings.Join(choices, ", "))
+	return defaultValue
+}
+
+// getEnvDurationMap parses "key=duration" pairs separated by commas
+func getEnvDurationMap(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for name, value := range getEnvPairs(key) {
+		d, err := ParseDuration(value)
+		if err != nil {
+			log.Printf("Invalid value for %s (%s): %v", key, name, err)
+			continue
+		}
+		result[name] = d
+	}
+	return result
+}
+
+// getEnvIntMap parses "key=number" pairs separated by commas
+func getEnvIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for name, value := range getEnvPairs(key) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Invalid value for %s (%s): %v", key, name, err)
+			continue
+		}
+		result[name] = n
+	}
+	return result
+}
+
+// getEnvList splits a comma separated environment variable
+func getEnvList(key, defaultValue string) []string {
+	var result []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// getEnvRoutes parses "pattern=channel|channel" pairs separated by commas
+func getEnvRoutes(key string) map[string][]string {
+	result := make(map[string][]string)
+	for pattern, value := range getEnvPairs(key) {
+		for _, channel := range strings.Split(value, "|") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				result[pattern] = append(result[pattern], channel)
+			}
+		}
+	}
+	return result
+}
+
+// getEnvRestartRules parses "pattern=attempts/window" rules separated by
+// commas, keeping their order
+func getEnvRestartRules(key string) []RestartRule {
+	var rules []RestartRule
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		pattern, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || pattern == "" {
+			continue
+		}
+
+		attempts, window, _ := strings.Cut(value, "/")
+		n, err := strconv.Atoi(strings.TrimSpace(attempts))
+		if err != nil || n <= 0 {
+			log.Printf("Invalid attempts for %s (%s): %q", key, pattern, attempts)
+			continue
+		}
+		d, err := ParseDuration(strings.TrimSpace(window))
+		if err != nil || d <= 0 {
+			log.Printf("Invalid window for %s (%s): %q", key, pattern, window)
+			continue
+		}
+
+		rules = append(rules, RestartRule{Pattern: strings.TrimSpace(pattern), MaxAttempts: n, Window: d})
+	}
+	return rules
+}
+
+// getEnvExitPolicies parses "selector=policy" rules separated by semicolons,
+// keeping their order. Policies list codes with commas, so rules cannot be
+// separated by them. Label selectors contain "=" themselves, so the policy
+// follows the last one.
+func getEnvExitPolicies(key string) []ExitPolicy {
+	var policies []ExitPolicy
+	for _, rule := range strings.Split(os.Getenv(key), ";") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 || strings.TrimSpace(rule[:i]) == "" {
+			log.Printf("Invalid rule for %s, expected selector=policy: %q", key, rule)
+			continue
+		}
+		policies = append(policies, ExitPolicy{
+			Selector: strings.TrimSpace(rule[:i]),
+			Policy:   strings.TrimSpace(rule[i+1:]),
+		})
+	}
+	return policies
+}
+
+// getEnvPairs splits a "key=value,key=value" environment variable
+func getEnvPairs(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// ParseDuration parses a Go duration, additionally accepting a "d" suffix
+// for whole days such as "30d"
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
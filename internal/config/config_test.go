@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90s", 90 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetEnvExitPolicies(t *testing.T) {
+	t.Setenv("EXIT_POLICIES", "batch-*=success:0,1 ignore:137; label:com.docker.compose.service=api=service;=service;broken")
+
+	want := []ExitPolicy{
+		{Selector: "batch-*", Policy: "success:0,1 ignore:137"},
+		{Selector: "label:com.docker.compose.service=api", Policy: "service"},
+	}
+	got := getEnvExitPolicies("EXIT_POLICIES")
+	if len(got) != len(want) {
+		t.Fatalf("getEnvExitPolicies() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("getEnvExitPolicies()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGetEnvChoice(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "info"},
+		{"warning", "warning"},
+		{"error", "error"},
+		{"critical", "info"},
+		{"Warning", "info"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("INGEST_MIN_SEVERITY", tt.value)
+		got := getEnvChoice("INGEST_MIN_SEVERITY", "info", "info", "success", "warning", "error")
+		if got != tt.want {
+			t.Errorf("getEnvChoice(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -22,10 +22,10 @@ func New(dataDir string) (*Database, error) {
 	if err := os.MkdirAll(dbPath, 0755); err != nil {
 		return nil, err
 	}
-	
+
 	// Set environment variable for PocketBase data directory
 	os.Setenv("PB_DATA_DIR", dbPath)
-	
+
 	app := pocketbase.New()
 	db := &Database{app: app}
 
@@ -44,26 +44,10 @@ func New(dataDir string) (*Database, error) {
 
 // setupCollections creates the required collections
 func (db *Database) setupCollections() error {
-	// Check if collections already exist
-	collections, err := db.app.Dao().FindCollectionsByType("base")
-	if err != nil {
-		log.Printf("Error checking existing collections: %v", err)
-		// Continue anyway to try creating them
-	}
-
-	// If we already have collections, skip setup
-	if len(collections) >= 4 {
-		log.Println("Collections already exist, skipping setup")
-		return nil
-	}
-
 	log.Println("Setting up database collections...")
 
 	// Create notifications collection
-	notificationsCollection := &models.Collection{}
-	notificationsCollection.Name = "notifications"
-	notificationsCollection.Type = models.CollectionTypeBase
-	notificationsCollection.Schema = schema.NewSchema(
+	db.ensureCollection("notifications",
 		&schema.SchemaField{
 			Name:     "name",
 			Type:     schema.FieldTypeText,
@@ -83,19 +67,54 @@ func (db *Database) setupCollections() error {
 			Name: "enabled",
 			Type: schema.FieldTypeBool,
 		},
+		&schema.SchemaField{
+			Name: "config",
+			Type: schema.FieldTypeJson,
+		},
+		&schema.SchemaField{
+			Name: "timezone",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "quiet_hours",
+			Type: schema.FieldTypeJson,
+		},
+		&schema.SchemaField{
+			Name: "fallback",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "reroute",
+			Type: schema.FieldTypeBool,
+		},
+		&schema.SchemaField{
+			Name: "health",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "consecutive_failures",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "last_error",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "last_tested",
+			Type: schema.FieldTypeDate,
+		},
+		&schema.SchemaField{
+			Name: "last_test_success",
+			Type: schema.FieldTypeBool,
+		},
+		&schema.SchemaField{
+			Name: "last_test_error",
+			Type: schema.FieldTypeText,
+		},
 	)
 
-	if err := db.app.Dao().SaveCollection(notificationsCollection); err != nil {
-		log.Printf("Error creating notifications collection: %v", err)
-	} else {
-		log.Println("✅ Created notifications collection")
-	}
-
 	// Create containers collection
-	containersCollection := &models.Collection{}
-	containersCollection.Name = "containers"
-	containersCollection.Type = models.CollectionTypeBase
-	containersCollection.Schema = schema.NewSchema(
+	db.ensureCollection("containers",
 		&schema.SchemaField{
 			Name:     "container_id",
 			Type:     schema.FieldTypeText,
@@ -122,19 +141,18 @@ func (db *Database) setupCollections() error {
 			Name: "status",
 			Type: schema.FieldTypeText,
 		},
+		&schema.SchemaField{
+			Name: "notify_on_actions",
+			Type: schema.FieldTypeJson,
+		},
+		&schema.SchemaField{
+			Name: "probes",
+			Type: schema.FieldTypeJson,
+		},
 	)
 
-	if err := db.app.Dao().SaveCollection(containersCollection); err != nil {
-		log.Printf("Error creating containers collection: %v", err)
-	} else {
-		log.Println("✅ Created containers collection")
-	}
-
 	// Create events_log collection
-	eventsCollection := &models.Collection{}
-	eventsCollection.Name = "events_log"
-	eventsCollection.Type = models.CollectionTypeBase
-	eventsCollection.Schema = schema.NewSchema(
+	db.ensureCollection("events_log",
 		&schema.SchemaField{
 			Name:     "container_id",
 			Type:     schema.FieldTypeText,
@@ -162,19 +180,178 @@ func (db *Database) setupCollections() error {
 			Name: "timestamp",
 			Type: schema.FieldTypeDate,
 		},
+		// Rollout timings in milliseconds, measured from container create
+		&schema.SchemaField{
+			Name: "time_to_running_ms",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "time_to_healthy_ms",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "image",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "incident_id",
+			Type: schema.FieldTypeText,
+		},
 	)
 
-	if err := db.app.Dao().SaveCollection(eventsCollection); err != nil {
-		log.Printf("Error creating events_log collection: %v", err)
-	} else {
-		log.Println("✅ Created events_log collection")
-	}
+	// Create silences collection
+	db.ensureCollection("silences",
+		&schema.SchemaField{
+			Name:     "container_name",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name:     "until",
+			Type:     schema.FieldTypeDate,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name: "reason",
+			Type: schema.FieldTypeText,
+		},
+	)
+
+	// Create deliveries collection
+	db.ensureCollection("deliveries",
+		&schema.SchemaField{
+			Name: "channel_id",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "channel_name",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "message",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "success",
+			Type: schema.FieldTypeBool,
+		},
+		&schema.SchemaField{
+			Name: "error",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "duration_ms",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "timestamp",
+			Type: schema.FieldTypeDate,
+		},
+	)
+
+	// Create jobs collection
+	db.ensureCollection("jobs",
+		&schema.SchemaField{
+			Name:     "name",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name: "every",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "grace",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "last_container_id",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "last_started",
+			Type: schema.FieldTypeDate,
+		},
+		&schema.SchemaField{
+			Name: "last_finished",
+			Type: schema.FieldTypeDate,
+		},
+		&schema.SchemaField{
+			Name: "last_status",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "last_duration_ms",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "last_exit_code",
+			Type: schema.FieldTypeNumber,
+		},
+		&schema.SchemaField{
+			Name: "overdue",
+			Type: schema.FieldTypeBool,
+		},
+	)
+
+	// Create heartbeats collection
+	db.ensureCollection("heartbeats",
+		&schema.SchemaField{
+			Name:     "name",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name:     "token",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name:     "interval",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name: "grace",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "channels",
+			Type: schema.FieldTypeJson,
+		},
+		&schema.SchemaField{
+			Name: "last_ping",
+			Type: schema.FieldTypeDate,
+		},
+		&schema.SchemaField{
+			Name: "status",
+			Type: schema.FieldTypeText,
+		},
+		&schema.SchemaField{
+			Name: "incident_id",
+			Type: schema.FieldTypeText,
+		},
+	)
+
+	// Create held_notifications collection
+	db.ensureCollection("held_notifications",
+		&schema.SchemaField{
+			Name:     "channel_id",
+			Type:     schema.FieldTypeText,
+			Required: true,
+		},
+		&schema.SchemaField{
+			Name: "event",
+			Type: schema.FieldTypeJson,
+		},
+		&schema.SchemaField{
+			Name: "timestamp",
+			Type: schema.FieldTypeDate,
+		},
+	)
 
 	// Create settings collection
-	settingsCollection := &models.Collection{}
-	settingsCollection.Name = "settings"
-	settingsCollection.Type = models.CollectionTypeBase
-	settingsCollection.Schema = schema.NewSchema(
+	db.ensureCollection("settings",
 		&schema.SchemaField{
 			Name:     "key",
 			Type:     schema.FieldTypeText,
@@ -186,16 +363,48 @@ func (db *Database) setupCollections() error {
 		},
 	)
 
-	if err := db.app.Dao().SaveCollection(settingsCollection); err != nil {
-		log.Printf("Error creating settings collection: %v", err)
-	} else {
-		log.Println("✅ Created settings collection")
-	}
-
 	log.Println("✅ Database setup completed")
 	return nil
 }
 
+// ensureCollection creates a base collection with the given fields, or adds
+// any fields missing from an existing collection so older data directories
+// pick up schema additions
+func (db *Database) ensureCollection(name string, fields ...*schema.SchemaField) {
+	collection, err := db.app.Dao().FindCollectionByNameOrId(name)
+	if err != nil {
+		collection = &models.Collection{}
+		collection.Name = name
+		collection.Type = models.CollectionTypeBase
+		collection.Schema = schema.NewSchema(fields...)
+
+		if err := db.app.Dao().SaveCollection(collection); err != nil {
+			log.Printf("Error creating %s collection: %v", name, err)
+		} else {
+			log.Printf("✅ Created %s collection", name)
+		}
+		return
+	}
+
+	changed := false
+	for _, field := range fields {
+		if collection.Schema.GetFieldByName(field.Name) == nil {
+			collection.Schema.AddField(field)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := db.app.Dao().SaveCollection(collection); err != nil {
+		log.Printf("Error updating %s collection: %v", name, err)
+	} else {
+		log.Printf("✅ Updated %s collection", name)
+	}
+}
+
 // App returns the PocketBase app instance
 func (db *Database) App() *pocketbase.PocketBase {
 	return db.app
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/resolvers"
+	"github.com/pocketbase/pocketbase/tools/search"
+)
+
+// MatchAll is a filter that matches every record. PocketBase rejects empty
+// filter expressions, so use this instead of "" to list a whole collection.
+const MatchAll = "id != ''"
+
+// CountRecordsByFilter counts the records of a collection matching a filter
+func (db *Database) CountRecordsByFilter(collectionNameOrId, filter string, params ...dbx.Params) (int, error) {
+	dao := db.app.Dao()
+
+	collection, err := dao.FindCollectionByNameOrId(collectionNameOrId)
+	if err != nil {
+		return 0, err
+	}
+
+	resolver := resolvers.NewRecordFieldResolver(dao, collection, nil, true)
+
+	expr, err := search.FilterData(filter).BuildExpr(resolver, params...)
+	if err != nil || expr == nil {
+		return 0, errors.New("invalid or empty filter expression")
+	}
+
+	query := dao.RecordQuery(collection).AndWhere(expr)
+	resolver.UpdateQuery(query)
+
+	var total int
+	if err := query.Select("count(*)").Row(&total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetSetting returns the value of a key in the settings collection, or an
+// empty string if it is not set
+func (db *Database) GetSetting(key string) string {
+	record, err := db.app.Dao().FindFirstRecordByData("settings", "key", key)
+	if err != nil {
+		return ""
+	}
+	return record.GetString("value")
+}
+
+// SetSetting stores a value in the settings collection
+func (db *Database) SetSetting(key, value string) error {
+	dao := db.app.Dao()
+
+	record, err := dao.FindFirstRecordByData("settings", "key", key)
+	if err != nil {
+		collection, err := dao.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+		record = models.NewRecord(collection)
+		record.Set("key", key)
+	}
+
+	record.Set("value", value)
+	return dao.SaveRecord(record)
+}
@@ -1,19 +1,37 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 // Client wraps the Docker client
 type Client struct {
 	cli *client.Client
 	ctx context.Context
+
+	hostOnce sync.Once
+	hostName string
+
+	requests sync.Map
 }
 
+// requestWindow is how long events are attributed to an action NotifyPipe
+// requested
+const requestWindow = time.Minute
+
 // NewClient creates a new Docker client
 func NewClient(socketPath string) (*Client, error) {
 	cli, err := client.NewClientWithOpts(
@@ -40,6 +58,119 @@ func (c *Client) GetContainer(id string) (types.ContainerJSON, error) {
 	return c.cli.ContainerInspect(c.ctx, id)
 }
 
+// StartContainer starts a stopped container
+func (c *Client) StartContainer(id string) error {
+	c.requests.Store(id, time.Now())
+	return c.cli.ContainerStart(c.ctx, id, container.StartOptions{})
+}
+
+// StopContainer stops a container, killing it after the timeout in seconds.
+// A nil timeout uses the container's own stop timeout.
+func (c *Client) StopContainer(id string, timeout *int) error {
+	c.requests.Store(id, time.Now())
+	return c.cli.ContainerStop(c.ctx, id, container.StopOptions{Timeout: timeout})
+}
+
+// RestartContainer restarts a container
+func (c *Client) RestartContainer(id string, timeout *int) error {
+	c.requests.Store(id, time.Now())
+	return c.cli.ContainerRestart(c.ctx, id, container.StopOptions{Timeout: timeout})
+}
+
+// KillContainer sends a signal to a container, SIGKILL if empty
+func (c *Client) KillContainer(id, signal string) error {
+	c.requests.Store(id, time.Now())
+	return c.cli.ContainerKill(c.ctx, id, signal)
+}
+
+// Requested reports whether NotifyPipe itself started, stopped, restarted or
+// killed a container within the last minute, so the events that follow can
+// be told apart from actions taken with other Docker clients
+func (c *Client) Requested(id string) bool {
+	at, ok := c.requests.Load(id)
+	return ok && time.Since(at.(time.Time)) < requestWindow
+}
+
+// TailLogs returns the last lines of a container's combined output
+func (c *Client) TailLogs(id string, lines int) (string, error) {
+	info, err := c.cli.ContainerInspect(c.ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := c.cli.ContainerLogs(c.ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	// Without a TTY, stdout and stderr are multiplexed into one stream
+	var buf bytes.Buffer
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(&buf, reader)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, reader)
+	}
+	return strings.TrimRight(buf.String(), "\n"), err
+}
+
+// Stats returns a single resource usage sample of a container
+func (c *Client) Stats(id string) (container.StatsResponse, error) {
+	var stats container.StatsResponse
+
+	reader, err := c.cli.ContainerStatsOneShot(c.ctx, id)
+	if err != nil {
+		return stats, err
+	}
+	defer reader.Body.Close()
+
+	err = json.NewDecoder(reader.Body).Decode(&stats)
+	return stats, err
+}
+
+// FollowLogs streams the log lines a container writes from since onwards,
+// until ctx is cancelled or the container stops
+func (c *Client) FollowLogs(ctx context.Context, id string, tty bool, since time.Time) (io.ReadCloser, error) {
+	reader, err := c.cli.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Since:      strconv.FormatInt(since.Unix(), 10),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tty {
+		return reader, nil
+	}
+
+	// Without a TTY, stdout and stderr are multiplexed into one stream
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, reader)
+		reader.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// HostName returns the name of the Docker host, falling back to the local
+// hostname if the daemon cannot be queried
+func (c *Client) HostName() string {
+	c.hostOnce.Do(func() {
+		if info, err := c.cli.Info(c.ctx); err == nil && info.Name != "" {
+			c.hostName = info.Name
+			return
+		}
+		c.hostName, _ = os.Hostname()
+	})
+	return c.hostName
+}
+
 // Close closes the Docker client
 func (c *Client) Close() error {
 	return c.cli.Close()
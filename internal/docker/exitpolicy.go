@@ -0,0 +1,199 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+)
+
+// Labels that set the exit policy of a container
+const (
+	exitSuccessLabel = "notifypipe.exit.success"
+	exitFailureLabel = "notifypipe.exit.failure"
+	exitIgnoreLabel  = "notifypipe.exit.ignore"
+	exitServiceLabel = "notifypipe.exit.service"
+)
+
+// Outcomes of a container exit
+const (
+	exitSuccess = "success"
+	exitFailure = "failure"
+	exitIgnore  = "ignore"
+)
+
+// codeRange is an inclusive range of exit codes
+type codeRange struct {
+	from, to int
+}
+
+// exitPolicy decides what an exit code means for a container. Ignored codes
+// win over failure codes, which win over success codes; any other code is a
+// failure. Services are expected to keep running, so their success codes
+// are failures too.
+type exitPolicy struct {
+	success []codeRange
+	failure []codeRange
+	ignore  []codeRange
+	service bool
+}
+
+// selectedPolicy is an exit policy for the containers a selector matches
+type selectedPolicy struct {
+	selector string
+	policy   exitPolicy
+}
+
+// defaultExitPolicy treats exit code 0 as success
+var defaultExitPolicy = exitPolicy{success: []codeRange{{0, 0}}}
+
+// classify returns the outcome of an exit code
+func (p exitPolicy) classify(code int) string {
+	switch {
+	case inRanges(p.ignore, code):
+		return exitIgnore
+	case inRanges(p.failure, code):
+		return exitFailure
+	case inRanges(p.success, code) && !p.service:
+		return exitSuccess
+	}
+	return exitFailure
+}
+
+// parseExitPolicies parses EXIT_POLICIES rules, skipping invalid ones
+func parseExitPolicies(rules []config.ExitPolicy) []selectedPolicy {
+	var policies []selectedPolicy
+	for _, rule := range rules {
+		policy, err := parseExitPolicy(rule.Policy)
+		if err != nil {
+			log.Printf("Invalid exit policy for %s: %v", rule.Selector, err)
+			continue
+		}
+		policies = append(policies, selectedPolicy{selector: rule.Selector, policy: policy})
+	}
+	return policies
+}
+
+// parseExitPolicy parses a policy such as "success:0-2 ignore:137" or
+// "service"
+func parseExitPolicy(spec string) (exitPolicy, error) {
+	policy := defaultExitPolicy
+	for _, part := range strings.Fields(spec) {
+		name, codes, _ := strings.Cut(part, ":")
+
+		var err error
+		switch name {
+		case "success":
+			policy.success, err = parseCodes(codes)
+		case "failure":
+			policy.failure, err = parseCodes(codes)
+		case "ignore":
+			policy.ignore, err = parseCodes(codes)
+		case "service":
+			policy.service = true
+		default:
+			err = fmt.Errorf("unknown setting %q", name)
+		}
+		if err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+// parseCodes parses exit codes and ranges separated by commas or pipes, such
+// as "0,2-4|137"
+func parseCodes(value string) ([]codeRange, error) {
+	var ranges []codeRange
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '|' }) {
+		from, to, isRange := strings.Cut(strings.TrimSpace(item), "-")
+		if !isRange {
+			to = from
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code %q", item)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid exit code range %q", item)
+		}
+		ranges = append(ranges, codeRange{start, end})
+	}
+	return ranges, nil
+}
+
+// inRanges reports whether a code is in any of the ranges
+func inRanges(ranges []codeRange, code int) bool {
+	for _, r := range ranges {
+		if code >= r.from && code <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// exitPolicy returns the exit policy of a container: its own labels, then
+// the first EXIT_POLICIES selector that matches its name or labels, then the
+// default of only 0 being a success
+func (em *EventMonitor) exitPolicy(containerName string, labels map[string]string) exitPolicy {
+	if policy, ok := labelExitPolicy(containerName, labels); ok {
+		return policy
+	}
+
+	for _, selected := range em.exitPolicies {
+		if matchSelector(selected.selector, containerName, labels) {
+			return selected.policy
+		}
+	}
+
+	return defaultExitPolicy
+}
+
+// labelExitPolicy builds an exit policy from container labels, if it has any
+func labelExitPolicy(containerName string, labels map[string]string) (exitPolicy, bool) {
+	policy := defaultExitPolicy
+	found := false
+
+	for label, codes := range map[string]*[]codeRange{
+		exitSuccessLabel: &policy.success,
+		exitFailureLabel: &policy.failure,
+		exitIgnoreLabel:  &policy.ignore,
+	} {
+		value, ok := labels[label]
+		if !ok {
+			continue
+		}
+		parsed, err := parseCodes(value)
+		if err != nil {
+			log.Printf("Invalid %s label on %s: %v", label, containerName, err)
+			continue
+		}
+		*codes = parsed
+		found = true
+	}
+
+	if value, ok := labels[exitServiceLabel]; ok {
+		policy.service = value == "true"
+		found = true
+	}
+
+	return policy, found
+}
+
+// matchSelector matches a container name pattern, or "label:key=value"
+// against the container's labels
+func matchSelector(selector, containerName string, labels map[string]string) bool {
+	if label, ok := strings.CutPrefix(selector, "label:"); ok {
+		key, value, _ := strings.Cut(label, "=")
+		actual, exists := labels[key]
+		return exists && (value == "" || actual == value)
+	}
+
+	matched, _ := path.Match(selector, containerName)
+	return matched
+}
@@ -0,0 +1,70 @@
+package docker
+
+import "testing"
+
+func TestExitPolicyClassify(t *testing.T) {
+	tests := []struct {
+		spec string
+		code int
+		want string
+	}{
+		{"", 0, exitSuccess},
+		{"", 1, exitFailure},
+		{"success:0,1", 1, exitSuccess},
+		{"success:0,1", 2, exitFailure},
+		{"success:0-2|5", 5, exitSuccess},
+		{"success:0-2|5", 4, exitFailure},
+		{"ignore:137", 137, exitIgnore},
+		{"failure:0", 0, exitFailure},
+		{"success:0-10 failure:3", 3, exitFailure},
+		{"failure:1 ignore:1", 1, exitIgnore},
+		{"service", 0, exitFailure},
+		{"service ignore:143", 143, exitIgnore},
+	}
+
+	for _, tt := range tests {
+		policy, err := parseExitPolicy(tt.spec)
+		if err != nil {
+			t.Errorf("parseExitPolicy(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := policy.classify(tt.code); got != tt.want {
+			t.Errorf("parseExitPolicy(%q).classify(%d) = %s, want %s", tt.spec, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseExitPolicyErrors(t *testing.T) {
+	for _, spec := range []string{
+		"success:a",
+		"success:4-2",
+		"ignore:1-",
+		"retry:1",
+	} {
+		if _, err := parseExitPolicy(spec); err == nil {
+			t.Errorf("parseExitPolicy(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestMatchSelector(t *testing.T) {
+	labels := map[string]string{"com.docker.compose.service": "api"}
+	tests := []struct {
+		selector string
+		name     string
+		want     bool
+	}{
+		{"batch-*", "batch-nightly", true},
+		{"batch-*", "web", false},
+		{"label:com.docker.compose.service=api", "web", true},
+		{"label:com.docker.compose.service=db", "web", false},
+		{"label:com.docker.compose.service", "web", true},
+		{"label:missing", "web", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchSelector(tt.selector, tt.name, labels); got != tt.want {
+			t.Errorf("matchSelector(%q, %q) = %v, want %v", tt.selector, tt.name, got, tt.want)
+		}
+	}
+}
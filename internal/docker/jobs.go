@@ -0,0 +1,209 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Labels that put a container in job mode and set its schedule
+const (
+	jobLabel      = "notifypipe.job"
+	jobEveryLabel = "notifypipe.job.every"
+	jobGraceLabel = "notifypipe.job.grace"
+)
+
+// jobCheckInterval is how often overdue jobs are looked for
+const jobCheckInterval = time.Minute
+
+// JobDue returns when a scheduled job is expected to have started again
+// before it is overdue, and false for jobs without a schedule
+func JobDue(record *models.Record, defaultGrace time.Duration) (time.Time, bool) {
+	every, err := config.ParseDuration(record.GetString("every"))
+	if err != nil || every <= 0 {
+		return time.Time{}, false
+	}
+
+	grace := defaultGrace
+	if value := record.GetString("grace"); value != "" {
+		if d, err := config.ParseDuration(value); err == nil {
+			grace = d
+		}
+	}
+
+	last := record.GetDateTime("last_started").Time()
+	if last.IsZero() {
+		last = record.GetDateTime("created").Time()
+	}
+	return last.Add(every + grace), true
+}
+
+// job returns the job record of a container in job mode. Containers are in
+// job mode when labelled notifypipe.job=true, which creates the record, or
+// when a job with their name was set up through the API.
+func (em *EventMonitor) job(containerName string, labels map[string]string) (*models.Record, bool) {
+	dao := em.db.App().Dao()
+
+	record, err := dao.FindFirstRecordByData("jobs", "name", containerName)
+	if err != nil {
+		record = nil
+	}
+	if labels[jobLabel] != "true" {
+		return record, record != nil
+	}
+
+	if record == nil {
+		collection, err := dao.FindCollectionByNameOrId("jobs")
+		if err != nil {
+			log.Printf("Error finding jobs collection: %v", err)
+			return nil, false
+		}
+		record = models.NewRecord(collection)
+		record.Set("name", containerName)
+	}
+
+	// Labels keep the schedule in line with the container definition
+	if every, ok := labels[jobEveryLabel]; ok {
+		record.Set("every", every)
+	}
+	if grace, ok := labels[jobGraceLabel]; ok {
+		record.Set("grace", grace)
+	}
+
+	return record, true
+}
+
+// handleJobStart records the start of a job run. Starts are not notified,
+// the run is reported once it completes.
+func (em *EventMonitor) handleJobStart(containerID, containerName string, record *models.Record, startTime time.Time) {
+	em.jobStarts.Store(containerID, startTime)
+
+	record.Set("last_container_id", containerID)
+	record.Set("last_started", startTime)
+	record.Set("overdue", false)
+	if err := em.db.App().Dao().SaveRecord(record); err != nil {
+		log.Printf("Error saving job: %v", err)
+	}
+
+	em.logEvent(containerID, containerName, "job_start", "info", fmt.Sprintf("Job %s started", containerName))
+	em.setContainerStatus(containerID, "running")
+
+	if info, err := em.client.GetContainer(containerID); err == nil {
+		em.watchLogs(info, startTime)
+	}
+}
+
+// handleJobDie reports a finished job run with its duration and logs
+func (em *EventMonitor) handleJobDie(containerID, containerName string, record *models.Record, exitCode, stopSignal string, oom bool, finishTime time.Time) {
+	started := record.GetDateTime("last_started").Time()
+	if at, ok := em.jobStarts.LoadAndDelete(containerID); ok {
+		started = at.(time.Time)
+	}
+
+	var duration time.Duration
+	if !started.IsZero() && finishTime.After(started) {
+		duration = finishTime.Sub(started)
+	}
+	took := formatDuration(duration)
+
+	status, result := "success", "completed"
+	message := fmt.Sprintf("Job %s completed in %s", containerName, took)
+	switch {
+	case oom:
+		status, result = "failure", "failed"
+		message = fmt.Sprintf("Job %s ran out of memory after %s", containerName, took)
+	case stopSignal != "":
+		status, result = "stopped", "stopped"
+		message = fmt.Sprintf("Job %s was stopped by signal %s after %s", containerName, stopSignal, took)
+	default:
+		if exitStatus, _ := em.exitStatus(containerName, em.containerLabels(containerID), exitCode); exitStatus == "failure" {
+			status, result = "failure", "failed"
+			message = fmt.Sprintf("Job %s failed with exit %s after %s", containerName, exitCode, took)
+		}
+	}
+
+	event := em.logEvent(containerID, containerName, "job", status, message)
+	em.setContainerStatus(containerID, "exited")
+
+	record.Set("last_finished", finishTime)
+	record.Set("last_status", result)
+	record.Set("last_duration_ms", duration.Milliseconds())
+	if code, err := strconv.Atoi(exitCode); err == nil {
+		record.Set("last_exit_code", code)
+		event.ExitCode = &code
+	}
+	if err := em.db.App().Dao().SaveRecord(record); err != nil {
+		log.Printf("Error saving job: %v", err)
+	}
+
+	notify := false
+	switch status {
+	case "success":
+		// Job mode is opt-in, so completions are what users want to hear
+		notify = !em.isSilenced(containerName)
+	case "failure":
+		notify = em.shouldNotify(containerID, containerName, "failure")
+	}
+	if !notify {
+		return
+	}
+
+	emoji := "✅"
+	if status == "failure" {
+		emoji = "❌"
+	}
+	event.Title = fmt.Sprintf("Job %s %s", containerName, result)
+	event.Message = fmt.Sprintf("%s %s", emoji, message)
+	event.AddField("Duration", took, true)
+	event.AddField("Exit code", exitCode, true)
+	event.Logs = em.tailLogs(containerID)
+	em.notifier.Notify(event)
+}
+
+// checkJobs reports scheduled jobs that have not started within their
+// interval and grace period, once per missed run
+func (em *EventMonitor) checkJobs() {
+	records, err := em.db.App().Dao().FindRecordsByFilter("jobs", "every != '' && overdue = false", "", 0, 0)
+	if err != nil {
+		log.Printf("Error finding jobs: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		due, ok := JobDue(record, em.config.JobGrace)
+		if !ok || now.Before(due) {
+			continue
+		}
+
+		record.Set("overdue", true)
+		if err := em.db.App().Dao().SaveRecord(record); err != nil {
+			log.Printf("Error saving job: %v", err)
+			continue
+		}
+
+		name := record.GetString("name")
+		containerID := record.GetString("last_container_id")
+		if containerID == "" {
+			containerID = "job:" + name
+		}
+
+		since := "it was set up"
+		if last := record.GetDateTime("last_started"); !last.IsZero() {
+			since = last.Time().UTC().Format(time.RFC1123)
+		}
+
+		message := fmt.Sprintf("Job %s has not started since %s (expected every %s)", name, since, record.GetString("every"))
+		event := em.logEvent(containerID, name, "job_missed", "failure", message)
+
+		if em.shouldNotify(containerID, name, "failure") {
+			event.Title = fmt.Sprintf("Job %s is overdue", name)
+			event.Message = "⏰ " + message
+			em.notifier.Notify(event)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+)
+
+// LifecycleActions are the container actions that can be notified on top of
+// success and failure
+var LifecycleActions = []string{"kill", "stop", "pause", "unpause", "restart", "oom", "destroy"}
+
+// lifecycleVerbs describe what happened to a container for each action
+var lifecycleVerbs = map[string]string{
+	"kill":    "was sent a signal",
+	"stop":    "stopped",
+	"pause":   "paused",
+	"unpause": "unpaused",
+	"restart": "restarted",
+	"oom":     "ran out of memory",
+	"destroy": "was removed",
+}
+
+// lifecycleStatuses are the container statuses actions lead to. Start and
+// die set theirs in their own handlers.
+var lifecycleStatuses = map[string]string{
+	"pause":   "paused",
+	"unpause": "running",
+	"stop":    "exited",
+	"destroy": statusRemoved,
+}
+
+// reloadSignals are signals containers commonly handle without exiting, so a
+// kill with one of them is not the start of a stop
+var reloadSignals = map[string]bool{
+	"SIGHUP": true, "1": true,
+	"SIGUSR1": true, "10": true,
+	"SIGUSR2": true, "12": true,
+	"SIGCONT": true, "18": true,
+	"SIGWINCH": true, "28": true,
+}
+
+// handleLifecycle logs a lifecycle action with who initiated it, updates the
+// container's status and notifies if the container opted in to the action
+func (em *EventMonitor) handleLifecycle(containerID, containerName, action string, attributes map[string]string) {
+	initiator := "outside NotifyPipe"
+	if em.client.Requested(containerID) {
+		initiator = "NotifyPipe"
+	}
+
+	status := "info"
+	emoji := "ℹ️"
+	message := "Container " + lifecycleVerbs[action]
+	switch action {
+	case "kill":
+		message = fmt.Sprintf("Container was sent signal %s", attributes["signal"])
+	case "oom":
+		initiator = "the kernel"
+		status = "warning"
+		emoji = "⚠️"
+	}
+
+	event := em.logEvent(containerID, containerName, action, status, fmt.Sprintf("%s (initiated by %s)", message, initiator))
+	if containerStatus, ok := lifecycleStatuses[action]; ok {
+		em.setContainerStatus(containerID, containerStatus)
+	}
+
+	if em.shouldNotifyAction(containerID, containerName, action) {
+		event.Title = fmt.Sprintf("%s %s", containerName, lifecycleVerbs[action])
+		event.Message = fmt.Sprintf("%s Container '%s' %s", emoji, containerName, lifecycleVerbs[action])
+		event.AddField("Action", action, true)
+		event.AddField("Signal", attributes["signal"], true)
+		event.AddField("Initiated by", initiator, true)
+		em.notifier.Notify(event)
+	}
+}
+
+// shouldNotifyAction checks whether a container opted in to notifications
+// for a lifecycle action, falling back to NOTIFY_ACTIONS
+func (em *EventMonitor) shouldNotifyAction(containerID, containerName, action string) bool {
+	if em.isSilenced(containerName) {
+		return false
+	}
+
+	record, err := em.db.App().Dao().FindFirstRecordByFilter("containers", "container_id = {:id}", dbx.Params{
+		"id": containerID,
+	})
+	if err == nil {
+		var actions []string
+		if err := record.UnmarshalJSONField("notify_on_actions", &actions); err == nil && actions != nil {
+			return slices.Contains(actions, action)
+		}
+	}
+
+	return slices.Contains(em.config.NotifyActions, action)
+}
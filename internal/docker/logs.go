@@ -0,0 +1,298 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Labels that opt a container in or out of log alerts and override the
+// patterns it is matched against
+const (
+	logAlertsLabel   = "notifypipe.logs"
+	logPatternsLabel = "notifypipe.logs.patterns"
+)
+
+// logContextWait is how long a match waits for the lines that follow it
+const logContextWait = 2 * time.Second
+
+// maxLogLine is the longest log line read, longer lines end the follower
+const maxLogLine = 1024 * 1024
+
+// logFollower is a running log stream of a container
+type logFollower struct {
+	cancel context.CancelFunc
+}
+
+// logMatch is a matched line waiting for its trailing context
+type logMatch struct {
+	pattern string
+	line    string
+	before  []string
+	after   []string
+}
+
+// logLimiter rate limits log alerts per container name
+type logLimiter struct {
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+// newLogLimiter creates an empty log limiter
+func newLogLimiter() *logLimiter {
+	return &logLimiter{
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// allow reports whether an alert may be sent for a container and, if so, how
+// many matches were suppressed since the previous alert
+func (ll *logLimiter) allow(containerName string, cooldown time.Duration, now time.Time) (bool, int) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	if last, ok := ll.last[containerName]; ok && now.Sub(last) < cooldown {
+		ll.suppressed[containerName]++
+		return false, 0
+	}
+
+	suppressed := ll.suppressed[containerName]
+	ll.last[containerName] = now
+	delete(ll.suppressed, containerName)
+	return true, suppressed
+}
+
+// watchRunning follows the logs and starts the probes of running containers
+// that opted in
+func (em *EventMonitor) watchRunning() {
+	containers, err := em.client.ListContainers()
+	if err != nil {
+		log.Printf("Error listing running containers: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		info, err := em.client.GetContainer(c.ID)
+		if err != nil {
+			continue
+		}
+		em.watchLogs(info, now)
+		em.watchProbes(info)
+	}
+}
+
+// watchLogs follows the logs of a container from since onwards if it opted
+// in to log alerts, replacing any earlier follower of the container
+func (em *EventMonitor) watchLogs(info types.ContainerJSON, since time.Time) {
+	name := strings.TrimPrefix(info.Name, "/")
+	patterns := em.logPatterns(name, info.Config)
+	if len(patterns) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(em.ctx)
+	follower := &logFollower{cancel: cancel}
+
+	em.followersMu.Lock()
+	if previous, ok := em.followers[info.ID]; ok {
+		previous.cancel()
+	}
+	em.followers[info.ID] = follower
+	em.followersMu.Unlock()
+
+	tty := info.Config != nil && info.Config.Tty
+
+	go func() {
+		defer func() {
+			cancel()
+			em.followersMu.Lock()
+			if em.followers[info.ID] == follower {
+				delete(em.followers, info.ID)
+			}
+			em.followersMu.Unlock()
+		}()
+
+		log.Printf("📜 Following logs of %s", name)
+		if err := em.followLogs(ctx, info.ID, name, tty, since, patterns); err != nil && ctx.Err() == nil {
+			log.Printf("Error following logs of %s: %v", name, err)
+		}
+	}()
+}
+
+// unwatchLogs stops following the logs of a container
+func (em *EventMonitor) unwatchLogs(containerID string) {
+	em.followersMu.Lock()
+	defer em.followersMu.Unlock()
+
+	if follower, ok := em.followers[containerID]; ok {
+		follower.cancel()
+		delete(em.followers, containerID)
+	}
+}
+
+// logPatterns returns the patterns a container's logs are matched against,
+// or nil if the container did not opt in to log alerts
+func (em *EventMonitor) logPatterns(containerName string, cfg *container.Config) []*regexp.Regexp {
+	var labels map[string]string
+	if cfg != nil {
+		labels = cfg.Labels
+	}
+
+	enabled := labels[logAlertsLabel] == "true"
+	if labels[logAlertsLabel] != "false" {
+		for _, pattern := range em.config.LogAlertContainers {
+			if matched, _ := path.Match(pattern, containerName); matched {
+				enabled = true
+			}
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	sources := em.config.LogAlertPatterns
+	if value := labels[logPatternsLabel]; value != "" {
+		sources = strings.Split(value, ",")
+	}
+
+	var patterns []*regexp.Regexp
+	for _, source := range sources {
+		if source = strings.TrimSpace(source); source == "" {
+			continue
+		}
+		re, err := regexp.Compile(source)
+		if err != nil {
+			log.Printf("Invalid log alert pattern for %s (%s): %v", containerName, source, err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns
+}
+
+// followLogs matches log lines against the patterns until the stream ends.
+// A match is reported with the lines around it once enough trailing lines
+// arrive, or after logContextWait.
+func (em *EventMonitor) followLogs(ctx context.Context, containerID, containerName string, tty bool, since time.Time, patterns []*regexp.Regexp) error {
+	stream, err := em.client.FollowLogs(ctx, containerID, tty, since)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	lines := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), maxLogLine)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		errs <- scanner.Err()
+	}()
+
+	contextLines := em.config.LogAlertContext
+	var before []string
+	var pending *logMatch
+	var wait <-chan time.Time
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if pending != nil {
+					em.handleLogMatch(containerID, containerName, pending)
+				}
+				select {
+				case err := <-errs:
+					return err
+				default:
+					return nil
+				}
+			}
+
+			if pending != nil {
+				pending.after = append(pending.after, line)
+				if len(pending.after) >= contextLines {
+					em.handleLogMatch(containerID, containerName, pending)
+					pending, wait = nil, nil
+				}
+			} else if re := matchLine(patterns, line); re != nil {
+				pending = &logMatch{
+					pattern: re.String(),
+					line:    line,
+					before:  append([]string(nil), before...),
+				}
+				wait = time.After(logContextWait)
+				if contextLines == 0 {
+					em.handleLogMatch(containerID, containerName, pending)
+					pending, wait = nil, nil
+				}
+			}
+
+			before = append(before, line)
+			if len(before) > contextLines {
+				before = before[len(before)-contextLines:]
+			}
+		case <-wait:
+			em.handleLogMatch(containerID, containerName, pending)
+			pending, wait = nil, nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// matchLine returns the first pattern matching a line, or nil
+func matchLine(patterns []*regexp.Regexp, line string) *regexp.Regexp {
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return re
+		}
+	}
+	return nil
+}
+
+// handleLogMatch logs a matched line and sends a notification unless the
+// container was alerted about within the cooldown
+func (em *EventMonitor) handleLogMatch(containerID, containerName string, match *logMatch) {
+	allowed, suppressed := em.logLimiter.allow(containerName, em.config.LogAlertCooldown, time.Now())
+	if !allowed {
+		return
+	}
+
+	event := em.logEvent(containerID, containerName, "log_match", "warning", fmt.Sprintf("Log matched %s: %s", match.pattern, match.line))
+
+	if em.shouldNotify(containerID, containerName, "failure") {
+		event.Title = fmt.Sprintf("%s logged %s", containerName, match.pattern)
+		event.Message = fmt.Sprintf("⚠️ Container '%s' logged a line matching %s: %s", containerName, match.pattern, match.line)
+		event.AddField("Pattern", match.pattern, true)
+		if suppressed > 0 {
+			event.AddField("Suppressed", fmt.Sprintf("%d matches since the last alert", suppressed), true)
+		}
+
+		lines := append(append(match.before, match.line), match.after...)
+		event.Logs = strings.Join(lines, "\n")
+		em.notifier.Notify(event)
+	}
+}
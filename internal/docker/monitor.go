@@ -4,34 +4,78 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
+	"github.com/fatlirmorina/notifypipe/internal/config"
 	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/eventlog"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
+	"github.com/fatlirmorina/notifypipe/internal/metrics"
 	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/models"
+	pbtypes "github.com/pocketbase/pocketbase/tools/types"
 )
 
+// maxReconnectBackoff caps the delay between event stream reconnects
+const maxReconnectBackoff = 30 * time.Second
+
+// notificationLogLines is how many log lines failure notifications include
+const notificationLogLines = 20
+
 // EventMonitor monitors Docker events
 type EventMonitor struct {
-	client      *Client
-	db          *database.Database
-	notifier    *notifications.Manager
-	ctx         context.Context
-	cancelFunc  context.CancelFunc
+	client       *Client
+	db           *database.Database
+	notifier     *notifications.Manager
+	hub          *hub.Hub
+	config       *config.Config
+	rollouts     *rolloutTracker
+	restarts     *restartTracker
+	logLimiter   *logLimiter
+	exitPolicies []selectedPolicy
+	images       sync.Map
+	labels       sync.Map
+	incidents    sync.Map
+	stopping     sync.Map
+	oom          sync.Map
+	jobStarts    sync.Map
+	usage        sync.Map
+	resources    map[string]*resourceState
+
+	followersMu sync.Mutex
+	followers   map[string]*logFollower
+
+	probersMu sync.Mutex
+	probers   map[string]*prober
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
 }
 
 // NewEventMonitor creates a new event monitor
-func NewEventMonitor(client *Client, db *database.Database, notifier *notifications.Manager) *EventMonitor {
+func NewEventMonitor(client *Client, db *database.Database, notifier *notifications.Manager, eventHub *hub.Hub, cfg *config.Config) *EventMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &EventMonitor{
-		client:     client,
-		db:         db,
-		notifier:   notifier,
-		ctx:        ctx,
-		cancelFunc: cancel,
+		client:       client,
+		db:           db,
+		notifier:     notifier,
+		hub:          eventHub,
+		config:       cfg,
+		rollouts:     newRolloutTracker(),
+		restarts:     newRestartTracker(),
+		logLimiter:   newLogLimiter(),
+		exitPolicies: parseExitPolicies(cfg.ExitPolicies),
+		followers:    make(map[string]*logFollower),
+		probers:      make(map[string]*prober),
+		resources:    make(map[string]*resourceState),
+		ctx:          ctx,
+		cancelFunc:   cancel,
 	}
 }
 
@@ -39,19 +83,77 @@ func NewEventMonitor(client *Client, db *database.Database, notifier *notificati
 func (em *EventMonitor) Start() error {
 	log.Println("🔍 Starting Docker event monitoring...")
 
-	eventsChan, errChan := em.client.cli.Events(em.ctx, types.EventsOptions{})
+	// Events from the start of the reconciliation on are replayed, so a
+	// container changing state while it runs is not missed
+	lastEvent := time.Now().Add(-time.Nanosecond)
+	em.reconcile("detected while offline")
+	em.watchRunning()
+	go em.pollResources()
+
+	// Periodic work runs between events so it never races them
+	var reconcileTick <-chan time.Time
+	if em.config.ReconcileInterval > 0 {
+		ticker := time.NewTicker(em.config.ReconcileInterval)
+		defer ticker.Stop()
+		reconcileTick = ticker.C
+	}
+	jobTicker := time.NewTicker(jobCheckInterval)
+	defer jobTicker.Stop()
+
+	backoff := time.Second
+
+	for {
+		// Resume just after the last event so nothing is missed or replayed
+		resume := lastEvent.Add(time.Nanosecond)
+		options := types.EventsOptions{
+			Since: fmt.Sprintf("%d.%09d", resume.Unix(), resume.Nanosecond()),
+		}
+
+		eventsChan, errChan := em.client.cli.Events(em.ctx, options)
+		err := em.consume(eventsChan, errChan, reconcileTick, jobTicker.C, &lastEvent, &backoff)
+
+		if em.ctx.Err() != nil {
+			log.Println("Stopping Docker event monitoring...")
+			return nil
+		}
+
+		log.Printf("Error receiving Docker event: %v (reconnecting in %s)", err, backoff)
+		metrics.EventStreamReconnects.Inc()
+
+		select {
+		case <-time.After(backoff):
+		case <-em.ctx.Done():
+			log.Println("Stopping Docker event monitoring...")
+			return nil
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
 
+// consume handles events from a single event stream until it fails
+func (em *EventMonitor) consume(eventsChan <-chan events.Message, errChan <-chan error, reconcileTick, jobTick <-chan time.Time, lastEvent *time.Time, backoff *time.Duration) error {
 	for {
 		select {
 		case event := <-eventsChan:
+			*lastEvent = time.Unix(0, event.TimeNano)
+			*backoff = time.Second
+			metrics.DockerEvents.WithLabelValues(string(event.Type), string(event.Action)).Inc()
 			em.handleEvent(event)
+		case <-reconcileTick:
+			em.reconcile("detected by reconciliation")
+		case <-jobTick:
+			em.checkJobs()
 		case err := <-errChan:
-			if err != nil {
-				log.Printf("Error receiving Docker event: %v", err)
+			if err == nil {
+				err = fmt.Errorf("event stream closed")
 			}
+			return err
 		case <-em.ctx.Done():
-			log.Println("Stopping Docker event monitoring...")
-			return nil
+			return em.ctx.Err()
 		}
 	}
 }
@@ -65,22 +167,86 @@ func (em *EventMonitor) handleEvent(event events.Message) {
 	containerID := event.Actor.ID
 	containerName := event.Actor.Attributes["name"]
 	action := event.Action
+	eventTime := time.Unix(0, event.TimeNano)
+
+	if image := event.Actor.Attributes["image"]; image != "" {
+		em.images.Store(containerID, image)
+	}
+	labels := containerLabels(event.Actor.Attributes)
+	if len(labels) > 0 {
+		em.labels.Store(containerID, labels)
+	}
 
 	log.Printf("📦 Container event: %s - %s (%s)", containerName, action, containerID[:12])
 
+	em.hub.Publish(hub.Message{
+		Type:          hub.TypeContainer,
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		Time:          eventTime,
+		Data: map[string]any{
+			"action":     string(action),
+			"image":      event.Actor.Attributes["image"],
+			"attributes": event.Actor.Attributes,
+		},
+	})
+
 	// Handle different event types
 	switch action {
 	case "start":
-		em.handleContainerStart(containerID, containerName)
+		if job, ok := em.job(containerName, labels); ok {
+			em.handleJobStart(containerID, containerName, job, eventTime)
+			return
+		}
+		em.handleContainerStart(containerID, containerName, eventTime)
+	case "kill":
+		// A kill precedes the die of containers stopped on purpose
+		if signal := event.Actor.Attributes["signal"]; !reloadSignals[signal] {
+			if signal == "" {
+				signal = "SIGKILL"
+			}
+			em.stopping.Store(containerID, signal)
+		}
+		em.handleLifecycle(containerID, containerName, string(action), event.Actor.Attributes)
+	case "oom":
+		em.oom.Store(containerID, true)
+		em.handleLifecycle(containerID, containerName, string(action), event.Actor.Attributes)
 	case "die":
-		em.handleContainerDie(containerID, containerName, event.Actor.Attributes["exitCode"])
+		em.rollouts.finish(containerID)
+		em.unwatchProbes(containerID)
+		var stopSignal string
+		if signal, ok := em.stopping.LoadAndDelete(containerID); ok {
+			stopSignal = signal.(string)
+		}
+		_, oom := em.oom.LoadAndDelete(containerID)
+		if job, ok := em.job(containerName, labels); ok {
+			em.handleJobDie(containerID, containerName, job, event.Actor.Attributes["exitCode"], stopSignal, oom, eventTime)
+			return
+		}
+		em.handleContainerDie(containerID, containerName, event.Actor.Attributes["exitCode"], stopSignal, oom)
+	case "stop", "pause", "unpause", "restart":
+		em.handleLifecycle(containerID, containerName, string(action), event.Actor.Attributes)
 	case "create":
+		em.rollouts.begin(containerID, eventTime)
 		em.handleContainerCreate(containerID, containerName)
+	case events.ActionHealthStatusHealthy:
+		em.handleContainerHealthy(containerID, containerName, eventTime)
+	case events.ActionHealthStatusUnhealthy:
+		em.handleContainerUnhealthy(containerID, containerName)
+	case "destroy":
+		em.images.Delete(containerID)
+		em.labels.Delete(containerID)
+		em.stopping.Delete(containerID)
+		em.oom.Delete(containerID)
+		em.jobStarts.Delete(containerID)
+		em.unwatchLogs(containerID)
+		em.unwatchProbes(containerID)
+		em.handleLifecycle(containerID, containerName, string(action), event.Actor.Attributes)
 	}
 }
 
 // handleContainerStart handles container start events
-func (em *EventMonitor) handleContainerStart(containerID, containerName string) {
+func (em *EventMonitor) handleContainerStart(containerID, containerName string, startTime time.Time) {
 	// Wait a bit to ensure container is actually running
 	time.Sleep(2 * time.Second)
 
@@ -94,34 +260,114 @@ func (em *EventMonitor) handleContainerStart(containerID, containerName string)
 		return
 	}
 
+	startedAt, _ := time.Parse(time.RFC3339Nano, containerInfo.State.StartedAt)
+	timeToRunning := em.rollouts.markRunning(containerID, startTime, startedAt)
+
+	em.setContainerStatus(containerID, "running")
+
+	if startedAt.IsZero() {
+		startedAt = startTime
+	}
+	em.watchLogs(containerInfo, startedAt)
+	em.watchProbes(containerInfo)
+
 	// Log event
-	em.logEvent(containerID, containerName, "start", "success", "Container started successfully")
+	event := em.logEventWithData(containerID, containerName, "start", "success", "Container started successfully", map[string]any{
+		"time_to_running_ms": timeToRunning.Milliseconds(),
+	})
+
+	// Containers with a healthcheck report success once they become healthy
+	if containerInfo.State.Health != nil {
+		return
+	}
 
 	// Check if we should notify
-	if em.shouldNotify(containerID, "success") {
-		message := fmt.Sprintf("✅ Container '%s' deployed successfully", containerName)
-		em.notifier.Send(message)
+	if em.shouldNotify(containerID, containerName, "success") {
+		event.Title = fmt.Sprintf("Deployed %s", containerName)
+		event.Message = fmt.Sprintf("✅ Container '%s' deployed successfully%s", containerName, formatTimings(timeToRunning, 0))
+		event.AddField("Time to running", formatDuration(timeToRunning), true)
+		em.notifier.Notify(event)
+	}
+}
+
+// handleContainerHealthy handles the first healthy status of a rollout
+func (em *EventMonitor) handleContainerHealthy(containerID, containerName string, healthyTime time.Time) {
+	r := em.rollouts.markHealthy(containerID, healthyTime)
+	if r == nil {
+		return
+	}
+
+	event := em.logEventWithData(containerID, containerName, "healthy", "success", "Container is healthy", map[string]any{
+		"time_to_running_ms": r.running.Milliseconds(),
+		"time_to_healthy_ms": r.healthy.Milliseconds(),
+	})
+
+	if em.shouldNotify(containerID, containerName, "success") {
+		event.Title = fmt.Sprintf("Deployed %s", containerName)
+		event.Message = fmt.Sprintf("✅ Container '%s' deployed successfully%s", containerName, formatTimings(r.running, r.healthy))
+		event.AddField("Time to running", formatDuration(r.running), true)
+		event.AddField("Time to healthy", formatDuration(r.healthy), true)
+		em.notifier.Notify(event)
 	}
 }
 
-// handleContainerDie handles container die events
-func (em *EventMonitor) handleContainerDie(containerID, containerName, exitCode string) {
+// handleContainerUnhealthy handles containers whose healthcheck starts failing
+func (em *EventMonitor) handleContainerUnhealthy(containerID, containerName string) {
+	event := em.logEvent(containerID, containerName, "unhealthy", "failure", "Container healthcheck is failing")
+
+	if em.shouldNotify(containerID, containerName, "failure") {
+		event.Title = fmt.Sprintf("%s is unhealthy", containerName)
+		event.Message = fmt.Sprintf("❌ Container '%s' is unhealthy", containerName)
+		event.Logs = em.tailLogs(containerID)
+		em.notifier.Notify(event)
+	}
+}
+
+// handleContainerDie handles container die events. A die after a kill is a
+// stop and a die after an out of memory kill is always a failure; otherwise
+// the container's exit policy decides. Failed containers are restarted when
+// a restart rule matches.
+func (em *EventMonitor) handleContainerDie(containerID, containerName, exitCode, stopSignal string, oom bool) {
 	status := "failure"
 	message := fmt.Sprintf("Container stopped with exit code %s", exitCode)
 
-	// If exit code is 0, it's a graceful shutdown
-	if exitCode == "0" {
+	switch {
+	case oom:
+		message = fmt.Sprintf("Container was killed for running out of memory (exit code %s)", exitCode)
+	case stopSignal != "":
 		status = "stopped"
-		message = "Container stopped gracefully"
+		message = fmt.Sprintf("Container stopped by signal %s (exit code %s)", stopSignal, exitCode)
+	default:
+		status, message = em.exitStatus(containerName, em.containerLabels(containerID), exitCode)
 	}
 
 	// Log event
-	em.logEvent(containerID, containerName, "die", status, message)
+	event := em.logEvent(containerID, containerName, "die", status, message)
+	em.setContainerStatus(containerID, "exited")
+	if code, err := strconv.Atoi(exitCode); err == nil {
+		event.ExitCode = &code
+	}
+
+	var remediation string
+	if status == "failure" {
+		remediation = em.autoRestart(containerID, containerName)
+	}
 
-	// Only notify on failures (non-zero exit codes)
-	if exitCode != "0" && em.shouldNotify(containerID, "failure") {
-		notifMessage := fmt.Sprintf("❌ Container '%s' failed to deploy. Exit code: %s", containerName, exitCode)
-		em.notifier.Send(notifMessage)
+	// Only notify on failures
+	if status == "failure" && em.shouldNotify(containerID, containerName, "failure") {
+		event.Title = fmt.Sprintf("%s exited with code %s", containerName, exitCode)
+		event.Message = fmt.Sprintf("❌ Container '%s' failed to deploy. Exit code: %s", containerName, exitCode)
+		if oom {
+			event.Title = fmt.Sprintf("%s ran out of memory", containerName)
+			event.Message = fmt.Sprintf("❌ Container '%s' was killed for running out of memory. Exit code: %s", containerName, exitCode)
+		}
+		if remediation != "" {
+			event.Message += fmt.Sprintf(" (%s)", remediation)
+		}
+		event.AddField("Exit code", exitCode, true)
+		event.AddField("Auto-restart", remediation, true)
+		event.Logs = em.tailLogs(containerID)
+		em.notifier.Notify(event)
 	}
 }
 
@@ -134,12 +380,17 @@ func (em *EventMonitor) handleContainerCreate(containerID, containerName string)
 	}
 
 	// Store or update container in database
-	em.upsertContainer(containerID, containerName, containerInfo.Config.Image)
+	em.upsertContainer(containerID, containerName, containerInfo.Config.Image, "created")
 }
 
 // shouldNotify checks if we should send notification for this container
-func (em *EventMonitor) shouldNotify(containerID, eventType string) bool {
-	records, err := em.db.App().Dao().FindRecordsByFilter("containers", "", "", 0, 0)
+func (em *EventMonitor) shouldNotify(containerID, containerName, eventType string) bool {
+	if em.isSilenced(containerName) {
+		log.Printf("🔕 Notifications for %s are muted", containerName)
+		return false
+	}
+
+	records, err := em.db.App().Dao().FindRecordsByFilter("containers", database.MatchAll, "", 0, 0)
 	if err != nil {
 		return false
 	}
@@ -158,29 +409,136 @@ func (em *EventMonitor) shouldNotify(containerID, eventType string) bool {
 	return eventType == "failure"
 }
 
-// logEvent logs an event to the database
-func (em *EventMonitor) logEvent(containerID, containerName, eventType, status, message string) {
-	collection, err := em.db.App().Dao().FindCollectionByNameOrId("events_log")
+// isSilenced checks whether notifications for a container are muted
+func (em *EventMonitor) isSilenced(containerName string) bool {
+	now, err := pbtypes.ParseDateTime(time.Now())
 	if err != nil {
-		log.Printf("Error finding events_log collection: %v", err)
-		return
+		return false
+	}
+
+	total, err := em.db.CountRecordsByFilter("silences", "container_name = {:name} && until > {:now}", dbx.Params{
+		"name": containerName,
+		"now":  now.String(),
+	})
+	return err == nil && total > 0
+}
+
+// exitStatus returns the event status and message of an exit code under the
+// container's exit policy
+func (em *EventMonitor) exitStatus(containerName string, labels map[string]string, exitCode string) (string, string) {
+	code, err := strconv.Atoi(exitCode)
+	if err != nil {
+		return "failure", fmt.Sprintf("Container stopped with exit code %s", exitCode)
 	}
 
-	record := models.NewRecord(collection)
-	record.Set("container_id", containerID)
-	record.Set("container_name", containerName)
-	record.Set("event_type", eventType)
-	record.Set("status", status)
-	record.Set("message", message)
-	record.Set("timestamp", time.Now())
+	policy := em.exitPolicy(containerName, labels)
+	switch policy.classify(code) {
+	case exitIgnore:
+		return "stopped", fmt.Sprintf("Container stopped with exit code %s (ignored by its exit policy)", exitCode)
+	case exitSuccess:
+		if code == 0 {
+			return "stopped", "Container stopped gracefully"
+		}
+		return "stopped", fmt.Sprintf("Container stopped with exit code %s (a success by its exit policy)", exitCode)
+	}
 
-	if err := em.db.App().Dao().SaveRecord(record); err != nil {
+	if policy.service && inRanges(policy.success, code) {
+		return "failure", fmt.Sprintf("Service stopped with exit code %s", exitCode)
+	}
+	return "failure", fmt.Sprintf("Container stopped with exit code %s", exitCode)
+}
+
+// containerLabels returns the labels last seen on a container's events
+func (em *EventMonitor) containerLabels(containerID string) map[string]string {
+	if labels, ok := em.labels.Load(containerID); ok {
+		return labels.(map[string]string)
+	}
+	return nil
+}
+
+// logEvent logs an event to the database
+func (em *EventMonitor) logEvent(containerID, containerName, eventType, status, message string) *notifications.Event {
+	return em.logEventWithData(containerID, containerName, eventType, status, message, nil)
+}
+
+// logEventWithData logs an event to the database along with extra fields and
+// returns it as a notification event
+func (em *EventMonitor) logEventWithData(containerID, containerName, eventType, status, message string, data map[string]any) *notifications.Event {
+	event := notifications.NewEvent(eventType, status, message)
+	event.Container = &notifications.ContainerInfo{ID: containerID, Name: containerName}
+	if image, ok := em.images.Load(containerID); ok {
+		event.Container.Image = image.(string)
+	}
+	if labels, ok := em.labels.Load(containerID); ok {
+		event.Container.Labels = labels.(map[string]string)
+	}
+	event.AddField("Container", containerName, true)
+	event.AddField("Image", event.Container.Image, true)
+
+	event.ID = eventlog.NewID()
+	event.IncidentID = em.incident(containerName, status, event.ID)
+
+	_, err := eventlog.Log(em.db, em.hub, eventlog.Entry{
+		ID:            event.ID,
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		EventType:     eventType,
+		Status:        status,
+		Message:       message,
+		Image:         event.Container.Image,
+		IncidentID:    event.IncidentID,
+		Timestamp:     event.Timestamp,
+		Data:          data,
+	})
+	if err != nil {
 		log.Printf("Error saving event log: %v", err)
 	}
+
+	return event
+}
+
+// tailLogs returns the last log lines of a container for a notification
+func (em *EventMonitor) tailLogs(containerID string) string {
+	logs, err := em.client.TailLogs(containerID, notificationLogLines)
+	if err != nil {
+		log.Printf("Error reading container logs: %v", err)
+		return ""
+	}
+	return logs
+}
+
+// incident returns the incident an event belongs to. A failure opens an
+// incident named after its event unless one is already open, and the next
+// success resolves it.
+func (em *EventMonitor) incident(containerName, status, eventID string) string {
+	switch status {
+	case "failure":
+		id, _ := em.incidents.LoadOrStore(containerName, eventID)
+		return id.(string)
+	case "success":
+		if id, ok := em.incidents.LoadAndDelete(containerName); ok {
+			return id.(string)
+		}
+	}
+	return ""
+}
+
+// containerLabels extracts the labels from Docker event attributes, which
+// also carry the container name, image and exit details
+func containerLabels(attributes map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range attributes {
+		switch key {
+		case "name", "image", "exitCode", "signal", "execDuration":
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
 }
 
 // upsertContainer creates or updates a container in the database
-func (em *EventMonitor) upsertContainer(containerID, containerName, image string) {
+func (em *EventMonitor) upsertContainer(containerID, containerName, image, status string) {
 	collection, err := em.db.App().Dao().FindCollectionByNameOrId("containers")
 	if err != nil {
 		log.Printf("Error finding containers collection: %v", err)
@@ -188,7 +546,7 @@ func (em *EventMonitor) upsertContainer(containerID, containerName, image string
 	}
 
 	// Try to find existing record
-	records, err := em.db.App().Dao().FindRecordsByFilter("containers", "", "", 0, 0)
+	records, err := em.db.App().Dao().FindRecordsByFilter("containers", database.MatchAll, "", 0, 0)
 	if err != nil {
 		log.Printf("Error finding container records: %v", err)
 		return
@@ -206,6 +564,7 @@ func (em *EventMonitor) upsertContainer(containerID, containerName, image string
 		// Update existing
 		existingRecord.Set("name", containerName)
 		existingRecord.Set("image", image)
+		existingRecord.Set("status", status)
 		if err := em.db.App().Dao().SaveRecord(existingRecord); err != nil {
 			log.Printf("Error updating container: %v", err)
 		}
@@ -217,7 +576,7 @@ func (em *EventMonitor) upsertContainer(containerID, containerName, image string
 		record.Set("image", image)
 		record.Set("notify_on_success", false)
 		record.Set("notify_on_failure", true) // Default: notify on failures
-		record.Set("status", "created")
+		record.Set("status", status)
 
 		if err := em.db.App().Dao().SaveRecord(record); err != nil {
 			log.Printf("Error creating container record: %v", err)
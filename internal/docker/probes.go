@@ -0,0 +1,414 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/pocketbase/dbx"
+)
+
+// Labels that attach a probe to a container, e.g. notifypipe.probe=http:8080/healthz
+const (
+	probeLabel       = "notifypipe.probe"
+	probeStatusLabel = "notifypipe.probe.status"
+	probeBodyLabel   = "notifypipe.probe.body"
+)
+
+// Probe types
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+)
+
+// probeClient makes HTTP probe requests. Redirects are not followed, so the
+// status checked is the one the container returned.
+var probeClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// maxProbeBody is how much of a response body is searched for the expected
+// text
+const maxProbeBody = 64 * 1024
+
+// Probe checks that a running container serves traffic on a port. HTTP
+// probes pass on the expected status, or any 2xx or 3xx status when none is
+// set, and a body containing the expected text. TCP probes pass when a
+// connection is accepted. Unset durations and thresholds use the PROBE_*
+// defaults.
+type Probe struct {
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type"`
+	Port      int    `json:"port"`
+	Path      string `json:"path,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	Body      string `json:"body,omitempty"`
+	Interval  string `json:"interval,omitempty"`
+	Timeout   string `json:"timeout,omitempty"`
+	Failures  int    `json:"failures,omitempty"`
+	Published bool   `json:"published,omitempty"`
+}
+
+// Validate checks a probe definition
+func (p Probe) Validate() error {
+	if p.Type != ProbeHTTP && p.Type != ProbeTCP {
+		return fmt.Errorf("unknown probe type %q, expected http or tcp", p.Type)
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		return fmt.Errorf("invalid probe port %d", p.Port)
+	}
+	if p.Status != 0 && (p.Status < 100 || p.Status > 599) {
+		return fmt.Errorf("invalid probe status %d", p.Status)
+	}
+	if p.Failures < 0 {
+		return fmt.Errorf("invalid probe failure threshold %d", p.Failures)
+	}
+	for _, value := range []string{p.Interval, p.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := config.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid probe duration %q", value)
+		}
+	}
+	return nil
+}
+
+// String describes a probe, e.g. "http :8080/healthz"
+func (p Probe) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if p.Type == ProbeHTTP {
+		return fmt.Sprintf("http :%d%s", p.Port, p.path())
+	}
+	return fmt.Sprintf("tcp :%d", p.Port)
+}
+
+// path returns the request path of an HTTP probe
+func (p Probe) path() string {
+	if p.Path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(p.Path, "/") {
+		return "/" + p.Path
+	}
+	return p.Path
+}
+
+// prober runs the probes of a container
+type prober struct {
+	cancel context.CancelFunc
+}
+
+// probeState tracks consecutive failures of a probe
+type probeState struct {
+	failures int
+	down     bool
+	since    time.Time
+}
+
+// WatchProbes restarts the probes of a running container, e.g. after they
+// were changed
+func (em *EventMonitor) WatchProbes(containerID string) {
+	if em == nil {
+		return
+	}
+	info, err := em.client.GetContainer(containerID)
+	if err != nil || info.State == nil || !info.State.Running {
+		em.unwatchProbes(containerID)
+		return
+	}
+	em.watchProbes(info)
+}
+
+// watchProbes starts the probes of a container, replacing any probes that
+// were already running for it
+func (em *EventMonitor) watchProbes(info types.ContainerJSON) {
+	name := strings.TrimPrefix(info.Name, "/")
+	var labels map[string]string
+	if info.Config != nil {
+		labels = info.Config.Labels
+	}
+
+	probes := em.probes(info.ID, name, labels)
+
+	em.probersMu.Lock()
+	if previous, ok := em.probers[info.ID]; ok {
+		previous.cancel()
+		delete(em.probers, info.ID)
+	}
+	if len(probes) == 0 {
+		em.probersMu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(em.ctx)
+	em.probers[info.ID] = &prober{cancel: cancel}
+	em.probersMu.Unlock()
+
+	for _, probe := range probes {
+		address, err := em.probeAddress(info, probe)
+		if err != nil {
+			log.Printf("Error resolving probe %s of %s: %v", probe, name, err)
+			continue
+		}
+
+		log.Printf("🩺 Probing %s of %s at %s", probe, name, address)
+		go em.runProbe(ctx, info.ID, name, probe, address)
+	}
+}
+
+// unwatchProbes stops the probes of a container
+func (em *EventMonitor) unwatchProbes(containerID string) {
+	em.probersMu.Lock()
+	defer em.probersMu.Unlock()
+
+	if p, ok := em.probers[containerID]; ok {
+		p.cancel()
+		delete(em.probers, containerID)
+	}
+}
+
+// probes returns the probes of a container: the ones set through the API,
+// or the one its labels describe
+func (em *EventMonitor) probes(containerID, containerName string, labels map[string]string) []Probe {
+	record, err := em.db.App().Dao().FindFirstRecordByFilter("containers", "container_id = {:id}", dbx.Params{
+		"id": containerID,
+	})
+	if err == nil {
+		var probes []Probe
+		if err := record.UnmarshalJSONField("probes", &probes); err == nil && probes != nil {
+			return probes
+		}
+	}
+
+	spec, ok := labels[probeLabel]
+	if !ok {
+		return nil
+	}
+
+	probe, err := parseProbe(spec)
+	if err == nil && labels[probeStatusLabel] != "" {
+		probe.Status, err = strconv.Atoi(labels[probeStatusLabel])
+	}
+	if err == nil {
+		probe.Body = labels[probeBodyLabel]
+		err = probe.Validate()
+	}
+	if err != nil {
+		log.Printf("Invalid %s label on %s: %v", probeLabel, containerName, err)
+		return nil
+	}
+	return []Probe{probe}
+}
+
+// parseProbe parses a probe label such as "http:8080/healthz" or "tcp:5432"
+func parseProbe(spec string) (Probe, error) {
+	probeType, target, ok := strings.Cut(spec, ":")
+	if !ok {
+		return Probe{}, fmt.Errorf("expected type:port, got %q", spec)
+	}
+
+	port, probePath, _ := strings.Cut(target, "/")
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return Probe{}, fmt.Errorf("invalid port %q", port)
+	}
+
+	probe := Probe{Type: probeType, Port: number}
+	if probePath != "" {
+		probe.Path = "/" + probePath
+	}
+	return probe, nil
+}
+
+// probeAddress returns the host and port a probe connects to: the container's
+// IP on its first network, or the port it is published on when the probe asks
+// for it or the container has no IP of its own
+func (em *EventMonitor) probeAddress(info types.ContainerJSON, probe Probe) (string, error) {
+	port := strconv.Itoa(probe.Port)
+
+	if info.HostConfig != nil && info.HostConfig.NetworkMode.IsHost() {
+		return net.JoinHostPort(em.config.ProbeHost, port), nil
+	}
+	if info.NetworkSettings == nil {
+		return "", fmt.Errorf("container has no network settings")
+	}
+
+	if !probe.Published {
+		networks := make([]string, 0, len(info.NetworkSettings.Networks))
+		for network := range info.NetworkSettings.Networks {
+			networks = append(networks, network)
+		}
+		sort.Strings(networks)
+
+		for _, network := range networks {
+			if settings := info.NetworkSettings.Networks[network]; settings != nil && settings.IPAddress != "" {
+				return net.JoinHostPort(settings.IPAddress, port), nil
+			}
+		}
+	}
+
+	for containerPort, bindings := range info.NetworkSettings.Ports {
+		if containerPort.Proto() != "tcp" || containerPort.Int() != probe.Port {
+			continue
+		}
+		for _, binding := range bindings {
+			host := binding.HostIP
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = em.config.ProbeHost
+			}
+			return net.JoinHostPort(host, binding.HostPort), nil
+		}
+	}
+
+	return "", fmt.Errorf("port %d is not published and the container has no IP address", probe.Port)
+}
+
+// runProbe checks a probe on its interval until the context is cancelled,
+// alerting once failures reach the threshold and again on recovery
+func (em *EventMonitor) runProbe(ctx context.Context, containerID, containerName string, probe Probe, address string) {
+	interval := em.probeDuration(probe.Interval, em.config.ProbeInterval)
+	timeout := em.probeDuration(probe.Timeout, em.config.ProbeTimeout)
+	threshold := probe.Failures
+	if threshold <= 0 {
+		threshold = em.config.ProbeFailures
+	}
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := &probeState{}
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		err := checkProbe(ctx, probe, address, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		em.recordProbe(containerID, containerName, probe, address, state, threshold, err)
+	}
+}
+
+// recordProbe updates the state of a probe with a result
+func (em *EventMonitor) recordProbe(containerID, containerName string, probe Probe, address string, state *probeState, threshold int, err error) {
+	now := time.Now()
+
+	if err == nil {
+		if state.down {
+			em.notifyProbe(containerID, containerName, probe, address, nil, now.Sub(state.since))
+		}
+		*state = probeState{}
+		return
+	}
+
+	state.failures++
+	if state.failures == 1 {
+		state.since = now
+	}
+	if !state.down && state.failures >= threshold {
+		state.down = true
+		em.notifyProbe(containerID, containerName, probe, address, err, now.Sub(state.since))
+	}
+}
+
+// notifyProbe logs and notifies a failing or recovered probe
+func (em *EventMonitor) notifyProbe(containerID, containerName string, probe Probe, address string, probeErr error, duration time.Duration) {
+	if probeErr != nil {
+		message := fmt.Sprintf("Probe %s is failing: %v", probe, probeErr)
+		event := em.logEvent(containerID, containerName, "probe_failed", "failure", message)
+
+		if em.shouldNotify(containerID, containerName, "failure") {
+			event.Title = fmt.Sprintf("%s is not responding", containerName)
+			event.Message = fmt.Sprintf("❌ Container '%s' probe %s is failing: %v", containerName, probe, probeErr)
+			event.AddField("Probe", probe.String(), true)
+			event.AddField("Address", address, true)
+			event.AddField("Failing for", formatDuration(duration), true)
+			event.Logs = em.tailLogs(containerID)
+			em.notifier.Notify(event)
+		}
+		return
+	}
+
+	message := fmt.Sprintf("Probe %s recovered after %s", probe, formatDuration(duration))
+	event := em.logEvent(containerID, containerName, "probe_recovered", "success", message)
+
+	if em.shouldNotify(containerID, containerName, "failure") {
+		event.Title = fmt.Sprintf("%s is responding again", containerName)
+		event.Message = fmt.Sprintf("✅ Container '%s' probe %s recovered after %s", containerName, probe, formatDuration(duration))
+		event.AddField("Probe", probe.String(), true)
+		em.notifier.Notify(event)
+	}
+}
+
+// probeDuration parses a probe duration, falling back to a default
+func (em *EventMonitor) probeDuration(value string, defaultValue time.Duration) time.Duration {
+	if d, err := config.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
+
+// checkProbe runs a probe once against an address
+func checkProbe(ctx context.Context, probe Probe, address string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if probe.Type == ProbeTCP {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+probe.path(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "NotifyPipe")
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if probe.Status != 0 && resp.StatusCode != probe.Status {
+		return fmt.Errorf("status %d, expected %d", resp.StatusCode, probe.Status)
+	}
+	if probe.Status == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400) {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if probe.Body != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), probe.Body) {
+			return fmt.Errorf("response does not contain %q", probe.Body)
+		}
+	}
+
+	return nil
+}
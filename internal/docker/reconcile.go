@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// statusRemoved marks containers that no longer exist in Docker
+const statusRemoved = "removed"
+
+// reconcile brings the containers collection in line with Docker: missing
+// containers get a record, removed ones are marked, and containers that
+// started or stopped without an event being seen get events logged with the
+// given note, e.g. "detected while offline"
+func (em *EventMonitor) reconcile(note string) {
+	containers, err := em.client.ListContainers()
+	if err != nil {
+		log.Printf("Error listing containers for reconciliation: %v", err)
+		return
+	}
+
+	records, err := em.db.App().Dao().FindRecordsByFilter("containers", database.MatchAll, "", 0, 0)
+	if err != nil {
+		log.Printf("Error finding container records: %v", err)
+		return
+	}
+
+	byID := make(map[string]*models.Record, len(records))
+	for _, record := range records {
+		byID[record.GetString("container_id")] = record
+	}
+
+	seen := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		seen[c.ID] = true
+
+		name := c.ID[:12]
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		record, ok := byID[c.ID]
+		if !ok {
+			em.upsertContainer(c.ID, name, c.Image, c.State)
+			continue
+		}
+
+		previous := record.GetString("status")
+		if previous == c.State {
+			continue
+		}
+
+		em.reconcileState(c, name, previous, note)
+		em.setContainerStatus(c.ID, c.State)
+	}
+
+	for id, record := range byID {
+		if seen[id] || record.GetString("status") == statusRemoved {
+			continue
+		}
+
+		name := record.GetString("name")
+		em.logEvent(id, name, "destroy", "info", fmt.Sprintf("Container removed (%s)", note))
+		em.setContainerStatus(id, statusRemoved)
+	}
+}
+
+// reconcileState logs a start or stop that happened between the stored state
+// of a container and its current one. Records still in the created state
+// predate state tracking, so their changes are not reported.
+func (em *EventMonitor) reconcileState(c types.Container, name, previous, note string) {
+	wasRunning := previous == "running"
+	isRunning := c.State == "running"
+	stopped := previous == "exited" || previous == "dead"
+
+	if wasRunning == isRunning || (isRunning && !stopped) {
+		return
+	}
+
+	info, err := em.client.GetContainer(c.ID)
+	if err != nil || info.State == nil {
+		log.Printf("Error getting container info: %v", err)
+		return
+	}
+
+	if isRunning {
+		data := map[string]any{}
+		if startedAt, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+			data["timestamp"] = startedAt.UTC()
+		}
+
+		event := em.logEventWithData(c.ID, name, "start", "success", fmt.Sprintf("Container started (%s)", note), data)
+		if em.shouldNotify(c.ID, name, "success") {
+			event.Title = fmt.Sprintf("%s started", name)
+			event.Message = fmt.Sprintf("✅ Container '%s' started (%s)", name, note)
+			em.notifier.Notify(event)
+		}
+		return
+	}
+
+	exitCode := strconv.Itoa(info.State.ExitCode)
+	status, message := em.exitStatus(name, c.Labels, exitCode)
+	if info.State.OOMKilled {
+		status = "failure"
+		message = fmt.Sprintf("Container was killed for running out of memory (exit code %s)", exitCode)
+	}
+	message = fmt.Sprintf("%s (%s)", message, note)
+
+	data := map[string]any{}
+	if finishedAt, err := time.Parse(time.RFC3339Nano, info.State.FinishedAt); err == nil && !finishedAt.IsZero() {
+		data["timestamp"] = finishedAt.UTC()
+	}
+
+	event := em.logEventWithData(c.ID, name, "die", status, message, data)
+	event.ExitCode = &info.State.ExitCode
+
+	if status == "failure" && em.shouldNotify(c.ID, name, "failure") {
+		event.Title = fmt.Sprintf("%s exited with code %s", name, exitCode)
+		event.Message = fmt.Sprintf("❌ Container '%s' stopped with exit code %s (%s)", name, exitCode, note)
+		event.AddField("Exit code", exitCode, true)
+		em.notifier.Notify(event)
+	}
+}
+
+// setContainerStatus stores the last known state of a container
+func (em *EventMonitor) setContainerStatus(containerID, status string) {
+	record, err := em.db.App().Dao().FindFirstRecordByFilter("containers", "container_id = {:id}", dbx.Params{
+		"id": containerID,
+	})
+	if err != nil || record.GetString("status") == status {
+		return
+	}
+
+	record.Set("status", status)
+	if err := em.db.App().Dao().SaveRecord(record); err != nil {
+		log.Printf("Error updating container status: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+)
+
+// autoRestartPausedKey is the settings key of the auto-restart kill switch
+const autoRestartPausedKey = "auto_restart_paused"
+
+// AutoRestartEnabled reports whether restart rules are applied
+func AutoRestartEnabled(db *database.Database) bool {
+	return db.GetSetting(autoRestartPausedKey) != "true"
+}
+
+// SetAutoRestart turns every restart rule on or off
+func SetAutoRestart(db *database.Database, enabled bool) error {
+	return db.SetSetting(autoRestartPausedKey, strconv.FormatBool(!enabled))
+}
+
+// restartTracker keeps recent automatic restarts keyed by container name, so
+// attempts are counted across the new IDs of recreated containers
+type restartTracker struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+}
+
+// newRestartTracker creates an empty restart tracker
+func newRestartTracker() *restartTracker {
+	return &restartTracker{attempts: make(map[string][]time.Time)}
+}
+
+// attempt records a restart and returns its number within the rule's window,
+// or 0 when the rule has no attempts left
+func (rt *restartTracker) attempt(containerName string, rule config.RestartRule, now time.Time) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var recent []time.Time
+	for _, at := range rt.attempts[containerName] {
+		if now.Sub(at) < rule.Window {
+			recent = append(recent, at)
+		}
+	}
+
+	if len(recent) >= rule.MaxAttempts {
+		rt.attempts[containerName] = recent
+		return 0
+	}
+
+	rt.attempts[containerName] = append(recent, now)
+	return len(recent) + 1
+}
+
+// restartRule returns the first rule whose pattern matches a container name
+func restartRule(rules []config.RestartRule, containerName string) (config.RestartRule, bool) {
+	for _, rule := range rules {
+		if matched, _ := path.Match(rule.Pattern, containerName); matched {
+			return rule, true
+		}
+	}
+	return config.RestartRule{}, false
+}
+
+// autoRestart restarts a failed container when a restart rule matches it and
+// returns a summary for the failure notification, or an empty string when no
+// rule applies
+func (em *EventMonitor) autoRestart(containerID, containerName string) string {
+	rule, ok := restartRule(em.config.RestartRules, containerName)
+	if !ok {
+		return ""
+	}
+
+	if !AutoRestartEnabled(em.db) {
+		log.Printf("⏸️ Auto-restart is paused, not restarting %s", containerName)
+		return "auto-restart paused"
+	}
+
+	// Docker restarts containers with a restart policy itself
+	info, err := em.client.GetContainer(containerID)
+	if err != nil {
+		log.Printf("Error getting container info: %v", err)
+		return ""
+	}
+	if info.HostConfig != nil {
+		if policy := info.HostConfig.RestartPolicy.Name; policy != "" && policy != "no" {
+			return ""
+		}
+	}
+
+	attempt := em.restarts.attempt(containerName, rule, time.Now())
+	if attempt == 0 {
+		summary := fmt.Sprintf("auto-restart gave up after %d attempts in %s", rule.MaxAttempts, rule.Window)
+		em.logEvent(containerID, containerName, "auto_restart", "warning", summary)
+		return summary
+	}
+
+	log.Printf("🔁 Auto-restarting %s (attempt %d/%d)", containerName, attempt, rule.MaxAttempts)
+	if err := em.client.RestartContainer(containerID, nil); err != nil {
+		summary := fmt.Sprintf("auto-restart attempt %d/%d failed: %v", attempt, rule.MaxAttempts, err)
+		em.logEvent(containerID, containerName, "auto_restart", "warning", summary)
+		return summary
+	}
+
+	summary := fmt.Sprintf("auto-restarted, attempt %d/%d", attempt, rule.MaxAttempts)
+	em.logEvent(containerID, containerName, "auto_restart", "info", "Container "+summary)
+	return summary
+}
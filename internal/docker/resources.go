@@ -0,0 +1,282 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
+)
+
+// Labels that override the resource thresholds of a container
+const (
+	cpuThresholdLabel    = "notifypipe.threshold.cpu"
+	memoryThresholdLabel = "notifypipe.threshold.memory"
+	thresholdForLabel    = "notifypipe.threshold.for"
+)
+
+// Resources with thresholds
+const (
+	resourceCPU    = "CPU"
+	resourceMemory = "Memory"
+)
+
+// Usage is the latest resource usage sample of a container. CPU is a
+// percentage of the CPUs the container may use, memory of its limit.
+type Usage struct {
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryUsage   uint64    `json:"memory_usage"`
+	MemoryLimit   uint64    `json:"memory_limit"`
+	MemoryPercent float64   `json:"memory_percent"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// thresholds are the usage percentages a container is alerted at once they
+// are exceeded for the sustain duration. Zero disables a threshold.
+type thresholds struct {
+	cpu     float64
+	memory  float64
+	sustain time.Duration
+}
+
+// breach is a resource over its threshold
+type breach struct {
+	since   time.Time
+	alerted bool
+}
+
+// resourceState is what the poller remembers about a running container
+type resourceState struct {
+	limits    thresholds
+	cpuCores  float64
+	cpuTotal  uint64
+	cpuSystem uint64
+	breaches  map[string]*breach
+}
+
+// Usage returns the latest resource usage of a container
+func (em *EventMonitor) Usage(containerID string) (Usage, bool) {
+	if em == nil {
+		return Usage{}, false
+	}
+	usage, ok := em.usage.Load(containerID)
+	if !ok {
+		return Usage{}, false
+	}
+	return usage.(Usage), true
+}
+
+// pollResources samples the resource usage of running containers until the
+// monitor stops
+func (em *EventMonitor) pollResources() {
+	if em.config.ResourceInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(em.config.ResourceInterval)
+	defer ticker.Stop()
+
+	for {
+		em.checkResources()
+
+		select {
+		case <-ticker.C:
+		case <-em.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkResources samples every running container and forgets the ones that
+// stopped
+func (em *EventMonitor) checkResources() {
+	containers, err := em.client.ListContainers()
+	if err != nil {
+		log.Printf("Error listing containers for resource usage: %v", err)
+		return
+	}
+
+	running := make(map[string]bool)
+	for _, c := range containers {
+		if c.State != "running" || len(c.Names) == 0 {
+			continue
+		}
+		running[c.ID] = true
+		em.checkContainerResources(c)
+	}
+
+	for id := range em.resources {
+		if !running[id] {
+			delete(em.resources, id)
+			em.usage.Delete(id)
+		}
+	}
+}
+
+// checkContainerResources samples a container and alerts on thresholds it
+// has exceeded for long enough, or recovered from
+func (em *EventMonitor) checkContainerResources(c types.Container) {
+	name := strings.TrimPrefix(c.Names[0], "/")
+
+	state, ok := em.resources[c.ID]
+	if !ok {
+		state = &resourceState{
+			limits:   em.thresholds(name, c.Labels),
+			breaches: make(map[string]*breach),
+		}
+		if info, err := em.client.GetContainer(c.ID); err == nil && info.HostConfig != nil {
+			state.cpuCores = float64(info.HostConfig.NanoCPUs) / 1e9
+		}
+		em.resources[c.ID] = state
+	}
+
+	stats, err := em.client.Stats(c.ID)
+	if err != nil {
+		log.Printf("Error reading resource usage of %s: %v", name, err)
+		return
+	}
+
+	now := time.Now()
+	usage := Usage{
+		MemoryUsage: memoryUsage(stats.MemoryStats),
+		MemoryLimit: stats.MemoryStats.Limit,
+		UpdatedAt:   now,
+	}
+	if usage.MemoryLimit > 0 {
+		usage.MemoryPercent = float64(usage.MemoryUsage) / float64(usage.MemoryLimit) * 100
+	}
+
+	// One-shot samples carry no previous reading, so CPU usage is the
+	// difference to the last poll
+	total := stats.CPUStats.CPUUsage.TotalUsage
+	system := stats.CPUStats.SystemUsage
+	hasCPU := state.cpuSystem > 0 && system > state.cpuSystem && total >= state.cpuTotal
+	if hasCPU {
+		cores := float64(stats.CPUStats.OnlineCPUs)
+		if cores == 0 {
+			cores = 1
+		}
+		allowed := cores
+		if state.cpuCores > 0 && state.cpuCores < cores {
+			allowed = state.cpuCores
+		}
+		usage.CPUPercent = float64(total-state.cpuTotal) / float64(system-state.cpuSystem) * cores / allowed * 100
+	} else if previous, ok := em.Usage(c.ID); ok {
+		usage.CPUPercent = previous.CPUPercent
+	}
+	state.cpuTotal, state.cpuSystem = total, system
+
+	em.usage.Store(c.ID, usage)
+
+	if hasCPU {
+		em.checkThreshold(c.ID, name, state, resourceCPU, usage.CPUPercent, state.limits.cpu, now)
+	}
+	if usage.MemoryLimit > 0 {
+		em.checkThreshold(c.ID, name, state, resourceMemory, usage.MemoryPercent, state.limits.memory, now)
+	}
+}
+
+// checkThreshold tracks a resource against its threshold, alerting once it
+// has been exceeded for the sustain duration and again when it recovers
+func (em *EventMonitor) checkThreshold(containerID, containerName string, state *resourceState, resource string, value, threshold float64, now time.Time) {
+	b := state.breaches[resource]
+
+	if threshold <= 0 || value < threshold {
+		if b != nil {
+			delete(state.breaches, resource)
+			if b.alerted {
+				em.notifyResource(containerID, containerName, resource, value, threshold, now.Sub(b.since), false)
+			}
+		}
+		return
+	}
+
+	if b == nil {
+		b = &breach{since: now}
+		state.breaches[resource] = b
+	}
+	if !b.alerted && now.Sub(b.since) >= state.limits.sustain {
+		b.alerted = true
+		em.notifyResource(containerID, containerName, resource, value, threshold, now.Sub(b.since), true)
+	}
+}
+
+// notifyResource logs and notifies a threshold breach or recovery
+func (em *EventMonitor) notifyResource(containerID, containerName, resource string, value, threshold float64, duration time.Duration, breached bool) {
+	usage := fmt.Sprintf("%.1f%%", value)
+
+	if breached {
+		message := fmt.Sprintf("%s usage at %s for %s (threshold %.0f%%)", resource, usage, formatDuration(duration), threshold)
+		event := em.logEvent(containerID, containerName, "resource_breach", "warning", message)
+
+		if em.shouldNotify(containerID, containerName, "failure") {
+			event.Title = fmt.Sprintf("%s %s usage is high", containerName, strings.ToLower(resource))
+			event.Message = fmt.Sprintf("⚠️ Container '%s' %s", containerName, message)
+			event.AddField("Usage", usage, true)
+			event.AddField("Threshold", fmt.Sprintf("%.0f%%", threshold), true)
+			event.AddField("For", formatDuration(duration), true)
+			em.notifier.Notify(event)
+		}
+		return
+	}
+
+	message := fmt.Sprintf("%s usage back to %s after %s over %.0f%%", resource, usage, formatDuration(duration), threshold)
+	event := em.logEvent(containerID, containerName, "resource_recovered", "info", message)
+
+	if em.shouldNotify(containerID, containerName, "failure") {
+		event.Severity = notifications.SeveritySuccess
+		event.Title = fmt.Sprintf("%s %s usage recovered", containerName, strings.ToLower(resource))
+		event.Message = fmt.Sprintf("✅ Container '%s' %s", containerName, message)
+		event.AddField("Usage", usage, true)
+		em.notifier.Notify(event)
+	}
+}
+
+// thresholds returns the configured thresholds with a container's label
+// overrides applied
+func (em *EventMonitor) thresholds(containerName string, labels map[string]string) thresholds {
+	t := thresholds{
+		cpu:     em.config.ResourceCPUThreshold,
+		memory:  em.config.ResourceMemoryThreshold,
+		sustain: em.config.ResourceThresholdFor,
+	}
+
+	for label, value := range map[string]*float64{cpuThresholdLabel: &t.cpu, memoryThresholdLabel: &t.memory} {
+		if labels[label] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(labels[label], 64)
+		if err != nil {
+			log.Printf("Invalid %s label on %s: %v", label, containerName, err)
+			continue
+		}
+		*value = f
+	}
+
+	if labels[thresholdForLabel] != "" {
+		d, err := config.ParseDuration(labels[thresholdForLabel])
+		if err != nil {
+			log.Printf("Invalid %s label on %s: %v", thresholdForLabel, containerName, err)
+		} else {
+			t.sustain = d
+		}
+	}
+
+	return t
+}
+
+// memoryUsage returns the memory a container uses without the page cache it
+// can give back, like docker stats
+func memoryUsage(stats container.MemoryStats) uint64 {
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if inactive, ok := stats.Stats[key]; ok && inactive < stats.Usage {
+			return stats.Usage - inactive
+		}
+	}
+	return stats.Usage
+}
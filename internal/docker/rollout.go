@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rollout tracks the startup timeline of a single container deploy
+type rollout struct {
+	begin     time.Time
+	running   time.Duration
+	healthy   time.Duration
+	isRunning bool
+}
+
+// rolloutTracker keeps in-flight rollouts keyed by container ID
+type rolloutTracker struct {
+	mu       sync.Mutex
+	rollouts map[string]*rollout
+}
+
+// newRolloutTracker creates an empty rollout tracker
+func newRolloutTracker() *rolloutTracker {
+	return &rolloutTracker{rollouts: make(map[string]*rollout)}
+}
+
+// begin starts a new rollout for a container at the given time
+func (rt *rolloutTracker) begin(containerID string, at time.Time) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.rollouts[containerID] = &rollout{begin: at}
+}
+
+// markRunning records the time the container reached the running state. A
+// start without a preceding create (a restart) begins a new rollout at the
+// start event instead.
+func (rt *rolloutTracker) markRunning(containerID string, startEvent, startedAt time.Time) time.Duration {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	r, ok := rt.rollouts[containerID]
+	if !ok || r.isRunning {
+		r = &rollout{begin: startEvent}
+		rt.rollouts[containerID] = r
+	}
+
+	if startedAt.IsZero() || startedAt.Before(r.begin) {
+		startedAt = startEvent
+	}
+
+	r.running = startedAt.Sub(r.begin)
+	r.isRunning = true
+	return r.running
+}
+
+// markHealthy records the time the container first reported healthy and
+// returns the rollout, or nil if the container has no rollout in progress
+func (rt *rolloutTracker) markHealthy(containerID string, at time.Time) *rollout {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	r, ok := rt.rollouts[containerID]
+	if !ok || r.healthy > 0 {
+		return nil
+	}
+
+	r.healthy = at.Sub(r.begin)
+	result := *r
+	return &result
+}
+
+// finish forgets a container's rollout
+func (rt *rolloutTracker) finish(containerID string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.rollouts, containerID)
+}
+
+// formatTimings renders rollout timings for notification messages
+func formatTimings(running, healthy time.Duration) string {
+	var parts []string
+	if running > 0 {
+		parts = append(parts, fmt.Sprintf("running in %s", formatDuration(running)))
+	}
+	if healthy > 0 {
+		parts = append(parts, fmt.Sprintf("healthy in %s", formatDuration(healthy)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// formatDuration rounds a duration to a human friendly precision
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,74 @@
+package eventlog
+
+import (
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
+	"github.com/fatlirmorina/notifypipe/internal/metrics"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// Entry is an event recorded in the events log. ID may be set beforehand,
+// so that an incident can refer to the entry that opens it.
+type Entry struct {
+	ID            string
+	ContainerID   string
+	ContainerName string
+	EventType     string
+	Status        string
+	Message       string
+	Image         string
+	IncidentID    string
+	Timestamp     time.Time
+	Data          map[string]any
+}
+
+// NewID returns an ID for an entry that is not saved yet
+func NewID() string {
+	return security.RandomStringWithAlphabet(models.DefaultIdLength, models.DefaultIdAlphabet)
+}
+
+// Log saves an entry in the events log, counts it and publishes it to live
+// updates
+func Log(db *database.Database, eventHub *hub.Hub, entry Entry) (*models.Record, error) {
+	collection, err := db.App().Dao().FindCollectionByNameOrId("events_log")
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.NewRecord(collection)
+	if entry.ID != "" {
+		record.SetId(entry.ID)
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	record.Set("container_id", entry.ContainerID)
+	record.Set("container_name", entry.ContainerName)
+	record.Set("event_type", entry.EventType)
+	record.Set("status", entry.Status)
+	record.Set("message", entry.Message)
+	record.Set("timestamp", entry.Timestamp)
+	record.Set("image", entry.Image)
+	record.Set("incident_id", entry.IncidentID)
+	for key, value := range entry.Data {
+		record.Set(key, value)
+	}
+
+	if err := db.App().Dao().SaveRecord(record); err != nil {
+		return nil, err
+	}
+
+	metrics.EventsLogged.WithLabelValues(entry.EventType, entry.Status).Inc()
+	eventHub.Publish(hub.Message{
+		Type:          hub.TypeEvent,
+		ContainerID:   entry.ContainerID,
+		ContainerName: entry.ContainerName,
+		Data:          record,
+	})
+
+	return record, nil
+}
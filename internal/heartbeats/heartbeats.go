@@ -0,0 +1,239 @@
+package heartbeats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/eventlog"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Heartbeat states
+const (
+	StatusNew  = "new"
+	StatusUp   = "up"
+	StatusLate = "late"
+)
+
+// eventPrefix prefixes heartbeat names in the container_id of their events
+const eventPrefix = "heartbeat:"
+
+// checkInterval is how often the scheduler looks for late heartbeats
+const checkInterval = 30 * time.Second
+
+// ErrNotFound is returned when a ping token matches no heartbeat
+var ErrNotFound = errors.New("heartbeat not found")
+
+// Scheduler notifies when heartbeats stop arriving and when they resume
+type Scheduler struct {
+	db       *database.Database
+	notifier *notifications.Manager
+	hub      *hub.Hub
+	grace    time.Duration
+
+	// Pings and late checks both save heartbeats, so one never overwrites
+	// the other with a stale record
+	mu sync.Mutex
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// NewScheduler creates a new heartbeat scheduler
+func NewScheduler(db *database.Database, notifier *notifications.Manager, eventHub *hub.Hub, cfg *config.Config) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:         db,
+		notifier:   notifier,
+		hub:        eventHub,
+		grace:      cfg.HeartbeatGrace,
+		ctx:        ctx,
+		cancelFunc: cancel,
+	}
+}
+
+// Start checks for late heartbeats until stopped
+func (s *Scheduler) Start() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkLate()
+
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	s.cancelFunc()
+}
+
+// Due returns when the next ping of a heartbeat is expected at the latest,
+// including its grace period
+func (s *Scheduler) Due(record *models.Record) (time.Time, bool) {
+	interval, err := config.ParseDuration(record.GetString("interval"))
+	if err != nil || interval <= 0 {
+		return time.Time{}, false
+	}
+
+	grace := s.grace
+	if value := record.GetString("grace"); value != "" {
+		if d, err := config.ParseDuration(value); err == nil {
+			grace = d
+		}
+	}
+
+	last := record.GetDateTime("last_ping").Time()
+	if last.IsZero() {
+		last = record.GetDateTime("created").Time()
+	}
+	return last.Add(interval + grace), true
+}
+
+// Ping records a ping for the heartbeat with the given token and notifies
+// if the heartbeat was late
+func (s *Scheduler) Ping(token string) (*models.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dao := s.db.App().Dao()
+	record, err := dao.FindFirstRecordByData("heartbeats", "token", token)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	previous := record.GetString("status")
+	lastPing := record.GetDateTime("last_ping").Time()
+	incidentID := record.GetString("incident_id")
+
+	record.Set("last_ping", now)
+	record.Set("status", StatusUp)
+	record.Set("incident_id", "")
+	if err := dao.SaveRecord(record); err != nil {
+		return nil, err
+	}
+
+	if previous == StatusLate {
+		name := record.GetString("name")
+		message := fmt.Sprintf("Heartbeat %s recovered", name)
+		if !lastPing.IsZero() {
+			message = fmt.Sprintf("Heartbeat %s recovered after %s without a ping", name, now.Sub(lastPing).Round(time.Second))
+		}
+
+		event := s.logEvent(name, "success", message, incidentID)
+		event.Title = fmt.Sprintf("Heartbeat %s recovered", name)
+		event.Message = "✅ " + message
+		s.notify(record, event)
+	}
+
+	return record, nil
+}
+
+// checkLate reports heartbeats whose ping is overdue, once until they
+// recover
+func (s *Scheduler) checkLate() {
+	records, err := s.db.App().Dao().FindRecordsByFilter("heartbeats", "status != 'late'", "", 0, 0)
+	if err != nil {
+		log.Printf("Error finding heartbeats: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		due, ok := s.Due(record)
+		if !ok || time.Now().Before(due) {
+			continue
+		}
+		s.markLate(record.Id)
+	}
+}
+
+// markLate reports a heartbeat as late unless a ping arrived since it was
+// found overdue
+func (s *Scheduler) markLate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.db.App().Dao().FindRecordById("heartbeats", id)
+	if err != nil || record.GetString("status") == StatusLate {
+		return
+	}
+	if due, ok := s.Due(record); !ok || time.Now().Before(due) {
+		return
+	}
+
+	name := record.GetString("name")
+	since := "it was created"
+	if last := record.GetDateTime("last_ping"); !last.IsZero() {
+		since = last.Time().UTC().Format(time.RFC1123)
+	}
+
+	message := fmt.Sprintf("Heartbeat %s has not pinged since %s (expected every %s)", name, since, record.GetString("interval"))
+	event := s.logEvent(name, "failure", message, "")
+
+	record.Set("status", StatusLate)
+	record.Set("incident_id", event.IncidentID)
+	if err := s.db.App().Dao().SaveRecord(record); err != nil {
+		log.Printf("Error saving heartbeat: %v", err)
+		return
+	}
+
+	log.Printf("⏰ Heartbeat %s is late", name)
+	event.Title = fmt.Sprintf("Heartbeat %s is late", name)
+	event.Message = "⏰ " + message
+	s.notify(record, event)
+}
+
+// logEvent logs a heartbeat event to the database and returns it as a
+// notification event. Late heartbeats open an incident that their recovery
+// resolves.
+func (s *Scheduler) logEvent(name, status, message, incidentID string) *notifications.Event {
+	event := notifications.NewEvent("heartbeat", status, message)
+	event.AddField("Heartbeat", name, true)
+
+	event.ID = eventlog.NewID()
+	event.IncidentID = incidentID
+	if status == "failure" {
+		event.IncidentID = event.ID
+	}
+
+	_, err := eventlog.Log(s.db, s.hub, eventlog.Entry{
+		ID:            event.ID,
+		ContainerID:   eventPrefix + name,
+		ContainerName: name,
+		EventType:     "heartbeat",
+		Status:        status,
+		Message:       message,
+		IncidentID:    event.IncidentID,
+		Timestamp:     event.Timestamp,
+	})
+	if err != nil {
+		log.Printf("Error saving event log: %v", err)
+	}
+
+	return event
+}
+
+// notify sends a heartbeat event to the heartbeat's channels, or to every
+// channel if it has none
+func (s *Scheduler) notify(record *models.Record, event *notifications.Event) {
+	var channels []string
+	if err := record.UnmarshalJSONField("channels", &channels); err == nil && len(channels) > 0 {
+		s.notifier.NotifyChannels(channels, event)
+		return
+	}
+	s.notifier.Notify(event)
+}
@@ -0,0 +1,147 @@
+package hub
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message types published to stream subscribers
+const (
+	TypeContainer = "container"
+	TypeEvent     = "event"
+	TypeDelivery  = "delivery"
+)
+
+// subscriberBuffer is how many messages a slow subscriber may fall behind
+// before further messages are dropped for it
+const subscriberBuffer = 64
+
+// Message is a single update pushed to stream subscribers
+type Message struct {
+	Type          string    `json:"type"`
+	ContainerID   string    `json:"container_id,omitempty"`
+	ContainerName string    `json:"container_name,omitempty"`
+	Host          string    `json:"host,omitempty"`
+	Time          time.Time `json:"time"`
+	Data          any       `json:"data"`
+}
+
+// Filter restricts which messages a subscriber receives. Empty fields match
+// everything; a non-empty field excludes messages that lack the attribute.
+type Filter struct {
+	Containers []string
+	Hosts      []string
+	Types      []string
+}
+
+// Subscriber receives messages matching its filter on C
+type Subscriber struct {
+	C      chan Message
+	filter Filter
+}
+
+// Hub fans out published messages to all subscribers
+type Hub struct {
+	host        string
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+}
+
+// New creates a new hub that stamps messages with the given host name
+func New(host string) *Hub {
+	return &Hub{
+		host:        host,
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Host returns the host name messages are stamped with
+func (h *Hub) Host() string {
+	if h == nil {
+		return ""
+	}
+	return h.host
+}
+
+// Subscribe registers a new subscriber
+func (h *Hub) Subscribe(filter Filter) *Subscriber {
+	sub := &Subscriber{
+		C:      make(chan Message, subscriberBuffer),
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// Publish sends a message to every matching subscriber without blocking
+func (h *Hub) Publish(msg Message) {
+	if h == nil {
+		return
+	}
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	if msg.Host == "" {
+		msg.Host = h.host
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.matches(msg) {
+			continue
+		}
+		select {
+		case sub.C <- msg:
+		default:
+			// Subscriber is too slow, drop the message rather than block
+		}
+	}
+}
+
+// matches reports whether a message passes the filter
+func (f Filter) matches(msg Message) bool {
+	if len(f.Types) > 0 && !contains(f.Types, msg.Type) {
+		return false
+	}
+	if len(f.Hosts) > 0 && !contains(f.Hosts, msg.Host) {
+		return false
+	}
+	if len(f.Containers) > 0 {
+		for _, container := range f.Containers {
+			if container == msg.ContainerName ||
+				(msg.ContainerID != "" && strings.HasPrefix(msg.ContainerID, container)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// contains reports whether value is in values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry holds the application metrics exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+// Application metrics exposed on /metrics
+var (
+	// DockerEvents counts raw events received from the Docker event stream
+	DockerEvents = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "notifypipe_docker_events_total",
+		Help: "Docker events received, by object type and action.",
+	}, []string{"type", "action"})
+
+	// EventsLogged counts events recorded in events_log
+	EventsLogged = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "notifypipe_events_logged_total",
+		Help: "Container events recorded, by event type and status.",
+	}, []string{"event_type", "status"})
+
+	// EventStreamReconnects counts reconnects to the Docker event stream
+	EventStreamReconnects = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Name: "notifypipe_event_stream_reconnects_total",
+		Help: "Reconnects to the Docker event stream after it was interrupted.",
+	})
+
+	// NotificationsSent counts successful deliveries per channel
+	NotificationsSent = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "notifypipe_notifications_sent_total",
+		Help: "Notifications delivered successfully, by channel.",
+	}, []string{"channel"})
+
+	// NotificationsFailed counts failed deliveries per channel
+	NotificationsFailed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "notifypipe_notifications_failed_total",
+		Help: "Notifications that failed to deliver, by channel.",
+	}, []string{"channel"})
+
+	// NotificationQueueDepth is the number of queued deliveries not yet
+	// completed
+	NotificationQueueDepth = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Name: "notifypipe_notification_queue_depth",
+		Help: "Notification deliveries waiting to be completed.",
+	})
+
+	// DeliveryDuration observes how long a delivery took per channel
+	DeliveryDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "notifypipe_notification_delivery_duration_seconds",
+		Help:    "Time taken to deliver a notification, by channel.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"channel"})
+)
+
+// containersDesc describes the containers by state, counted at scrape time
+var containersDesc = prometheus.NewDesc(
+	"notifypipe_containers",
+	"Containers known to Docker, by state.",
+	[]string{"state"}, nil,
+)
+
+// containerCollector counts containers by state from a source read on every
+// scrape, so concurrent scrapes never see a partial count
+type containerCollector struct {
+	mu     sync.Mutex
+	states func() ([]string, error)
+}
+
+var containers = &containerCollector{}
+
+func init() {
+	Registry.MustRegister(containers)
+}
+
+// SetContainerStates sets the source of the container states counted by the
+// notifypipe_containers metric
+func SetContainerStates(states func() ([]string, error)) {
+	containers.mu.Lock()
+	defer containers.mu.Unlock()
+	containers.states = states
+}
+
+// Describe implements prometheus.Collector
+func (c *containerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- containersDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *containerCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	states := c.states
+	c.mu.Unlock()
+	if states == nil {
+		return
+	}
+
+	list, err := states()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(containersDesc, err)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, state := range list {
+		counts[state]++
+	}
+	for state, n := range counts {
+		ch <- prometheus.MustNewConstMetric(containersDesc, prometheus.GaugeValue, float64(n), state)
+	}
+}
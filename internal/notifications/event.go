@@ -0,0 +1,124 @@
+package notifications
+
+import "time"
+
+// EventVersion is the version of the structured event payload
+const EventVersion = 1
+
+// Severity controls the color and emphasis of rich messages
+type Severity string
+
+// Severities of an event
+const (
+	SeverityInfo    Severity = "info"
+	SeveritySuccess Severity = "success"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Color returns the hex color of a severity
+func (s Severity) Color() string {
+	switch s {
+	case SeveritySuccess:
+		return "#2eb67d"
+	case SeverityWarning:
+		return "#ecb22e"
+	case SeverityError:
+		return "#e01e5a"
+	}
+	return "#2488ff"
+}
+
+// severityRanks orders severities from least to most important
+var severityRanks = map[Severity]int{
+	SeverityInfo:    0,
+	SeveritySuccess: 1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// Valid reports whether the severity is known
+func (s Severity) Valid() bool {
+	_, ok := severityRanks[s]
+	return ok
+}
+
+// AtLeast reports whether the severity is as important as min
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRanks[s] >= severityRanks[min]
+}
+
+// ContainerInfo describes the container an event is about
+type ContainerInfo struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Image  string            `json:"image,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Field is a labelled value shown alongside a rich message
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Link is a titled URL shown below a rich message
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Event is a structured notification. Providers with rich formatting render
+// the title, fields, links and logs; others receive a plain text version.
+// Webhook channels receive the whole event as JSON.
+type Event struct {
+	Version    int            `json:"version"`
+	ID         string         `json:"id,omitempty"`
+	Type       string         `json:"type"`
+	Status     string         `json:"status,omitempty"`
+	Severity   Severity       `json:"severity"`
+	Title      string         `json:"title,omitempty"`
+	Message    string         `json:"message"`
+	Fields     []Field        `json:"fields,omitempty"`
+	Links      []Link         `json:"links,omitempty"`
+	Logs       string         `json:"logs,omitempty"`
+	Container  *ContainerInfo `json:"container,omitempty"`
+	Host       string         `json:"host,omitempty"`
+	ExitCode   *int           `json:"exit_code,omitempty"`
+	IncidentID string         `json:"incident_id,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// NewEvent creates an event of the given type with a text message
+func NewEvent(eventType, status, message string) *Event {
+	return &Event{
+		Version:   EventVersion,
+		Type:      eventType,
+		Status:    status,
+		Severity:  severityOf(status),
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// AddField appends a field unless its value is empty
+func (e *Event) AddField(name, value string, inline bool) {
+	if value == "" {
+		return
+	}
+	e.Fields = append(e.Fields, Field{Name: name, Value: value, Inline: inline})
+}
+
+// severityOf maps an event status to a severity
+func severityOf(status string) Severity {
+	switch status {
+	case "success":
+		return SeveritySuccess
+	case "failure":
+		return SeverityError
+	case "warning":
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
@@ -0,0 +1,160 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Channel health states
+const (
+	HealthHealthy  = "healthy"
+	HealthDegraded = "degraded"
+	HealthFailing  = "failing"
+)
+
+// ChannelHealth returns the health of a channel, healthy if it never failed
+func ChannelHealth(record *models.Record) string {
+	if health := record.GetString("health"); health != "" {
+		return health
+	}
+	return HealthHealthy
+}
+
+// healthFor returns the health of a channel after consecutive failures. A
+// threshold of 0 disables its state.
+func (m *Manager) healthFor(failures int) string {
+	switch {
+	case failures == 0:
+		return HealthHealthy
+	case m.failingAfter > 0 && failures >= m.failingAfter:
+		return HealthFailing
+	case m.degradedAfter > 0 && failures >= m.degradedAfter:
+		return HealthDegraded
+	}
+	return HealthHealthy
+}
+
+// updateHealth records the outcome of a delivery on the channel and returns
+// its health before and after
+func (m *Manager) updateHealth(record *models.Record, sendErr error) (string, string) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	// Deliveries to a channel can overlap, so count from the stored state
+	current, err := m.db.App().Dao().FindRecordById("notifications", record.Id)
+	if err != nil {
+		return ChannelHealth(record), ChannelHealth(record)
+	}
+
+	previous := ChannelHealth(current)
+	failures := 0
+	lastError := ""
+	if sendErr != nil {
+		failures = current.GetInt("consecutive_failures") + 1
+		lastError = sendErr.Error()
+	}
+	health := m.healthFor(failures)
+
+	if failures == current.GetInt("consecutive_failures") && health == previous {
+		return previous, health
+	}
+
+	_, err = m.db.App().Dao().DB().Update("notifications", dbx.Params{
+		"health":               health,
+		"consecutive_failures": failures,
+		"last_error":           lastError,
+	}, dbx.HashExp{"id": record.Id}).Execute()
+	if err != nil {
+		log.Printf("Error updating health of %s: %v", record.GetString("name"), err)
+	}
+
+	record.Set("health", health)
+	record.Set("consecutive_failures", failures)
+	record.Set("last_error", lastError)
+
+	if health != previous {
+		log.Printf("🩺 Notification channel %s is %s", record.GetString("name"), health)
+	}
+	return previous, health
+}
+
+// fallbackFor returns the enabled fallback channel of a channel, or the
+// FALLBACK_CHANNEL default
+func (m *Manager) fallbackFor(record *models.Record) *models.Record {
+	name := record.GetString("fallback")
+	if name == "" {
+		name = m.fallback
+	}
+	if name == "" {
+		return nil
+	}
+
+	fallback, err := m.findChannel(name)
+	if err != nil {
+		log.Printf("Error finding fallback channel: %v", err)
+		return nil
+	}
+	if fallback.Id == record.Id || !fallback.GetBool("enabled") {
+		return nil
+	}
+	return fallback
+}
+
+// failover alerts the fallback channel when a channel starts failing or
+// recovers, and reroutes an event it failed to deliver if the channel asks
+// for it and the fallback was not among the event's recipients already
+func (m *Manager) failover(record *models.Record, event *Event, sendErr error, previous, health string, recipients map[string]bool) {
+	failing := health == HealthFailing
+	reroute := failing && sendErr != nil && record.GetBool("reroute")
+	if previous == health && !reroute {
+		return
+	}
+
+	fallback := m.fallbackFor(record)
+	if fallback == nil {
+		return
+	}
+	if recipients[fallback.Id] {
+		reroute = false
+	}
+
+	name := record.GetString("name")
+	switch {
+	case failing && previous != HealthFailing:
+		alert := NewEvent("channel_failing", "failure", fmt.Sprintf("⚠️ Notification channel '%s' is failing after %d failed deliveries: %v", name, record.GetInt("consecutive_failures"), sendErr))
+		alert.Title = fmt.Sprintf("Notification channel %s is failing", name)
+		alert.AddField("Channel", name, true)
+		alert.AddField("Type", record.GetString("type"), true)
+		if record.GetBool("reroute") {
+			alert.AddField("Rerouting", fmt.Sprintf("to %s until it recovers", fallback.GetString("name")), false)
+		}
+		m.deliverFallback(fallback, alert)
+	case previous == HealthFailing && health == HealthHealthy:
+		alert := NewEvent("channel_recovered", "success", fmt.Sprintf("✅ Notification channel '%s' recovered", name))
+		alert.Title = fmt.Sprintf("Notification channel %s recovered", name)
+		alert.AddField("Channel", name, true)
+		m.deliverFallback(fallback, alert)
+	}
+
+	if reroute {
+		rerouted := *event
+		rerouted.Fields = append(slices.Clone(event.Fields), Field{Name: "Rerouted from", Value: name, Inline: true})
+		m.deliverFallback(fallback, &rerouted)
+	}
+}
+
+// deliverFallback sends an event to a fallback channel. Its own failures are
+// tracked but never fail over again.
+func (m *Manager) deliverFallback(fallback *models.Record, event *Event) {
+	if event.Host == "" {
+		event.Host = m.hub.Host()
+	}
+	if m.hold(fallback, event) {
+		return
+	}
+	m.updateHealth(fallback, m.transmit(fallback, event))
+}
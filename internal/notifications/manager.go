@@ -1,26 +1,100 @@
 package notifications
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/containrrr/shoutrrr"
+	"github.com/fatlirmorina/notifypipe/internal/actions"
+	"github.com/fatlirmorina/notifypipe/internal/config"
 	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/hub"
+	"github.com/fatlirmorina/notifypipe/internal/metrics"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	pbtypes "github.com/pocketbase/pocketbase/tools/types"
 )
 
 // Manager manages notifications
 type Manager struct {
-	db *database.Database
+	db      *database.Database
+	hub     *hub.Hub
+	actions *actions.Signer
+	baseURL string
+	restart bool
+
+	// Channel health and failover
+	healthMu      sync.Mutex
+	degradedAfter int
+	failingAfter  int
+	fallback      string
+
+	// Deliveries waiting in a queue per channel, so a slow channel neither
+	// blocks the caller nor other channels
+	queuesMu sync.Mutex
+	queues   map[string]chan delivery
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// queueSize is how many deliveries a channel's queue holds before new ones
+// are dropped
+const queueSize = 100
+
+// delivery is an event waiting to be sent to a channel. Recipients are the
+// IDs of every channel the event was sent to.
+type delivery struct {
+	record     *models.Record
+	event      *Event
+	recipients map[string]bool
 }
 
 // NewManager creates a new notification manager
-func NewManager(db *database.Database) *Manager {
-	return &Manager{db: db}
+func NewManager(db *database.Database, eventHub *hub.Hub, cfg *config.Config) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		db:      db,
+		hub:     eventHub,
+		actions: actions.NewSigner(db, cfg),
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		restart: slices.Contains(cfg.ControlActions, actions.Restart),
+
+		degradedAfter: cfg.ChannelDegradedAfter,
+		failingAfter:  cfg.ChannelFailingAfter,
+		fallback:      cfg.FallbackChannel,
+
+		queues: make(map[string]chan delivery),
+
+		ctx:        ctx,
+		cancelFunc: cancel,
+	}
 }
 
-// Send sends a notification to all enabled channels
+// Actions returns the signer of action links in notifications
+func (m *Manager) Actions() *actions.Signer {
+	return m.actions
+}
+
+// Send sends a text notification to all enabled channels
 func (m *Manager) Send(message string) {
-	records, err := m.db.App().Dao().FindRecordsByFilter("notifications", "", "", 0, 0)
+	m.Notify(NewEvent("message", "", message))
+}
+
+// Notify queues an event for all enabled channels
+func (m *Manager) Notify(event *Event) {
+	if event.Container != nil && m.baseURL != "" {
+		if event.Severity == SeverityError {
+			event.Links = append(event.Links, m.actionLinks(event)...)
+		}
+		event.Links = append(event.Links, Link{Title: "Open dashboard", URL: m.baseURL})
+	}
+
+	records, err := m.db.App().Dao().FindRecordsByFilter("notifications", database.MatchAll, "", 0, 0)
 	if err != nil {
 		log.Printf("Error fetching notifications: %v", err)
 		return
@@ -31,39 +105,271 @@ func (m *Manager) Send(message string) {
 		return
 	}
 
+	recipients := make(map[string]bool)
+	var enabled []*models.Record
 	for _, record := range records {
-		if !record.GetBool("enabled") {
+		if record.GetBool("enabled") {
+			recipients[record.Id] = true
+			enabled = append(enabled, record)
+		}
+	}
+
+	for _, record := range enabled {
+		m.enqueue(record, event, recipients)
+	}
+}
+
+// SendToChannel sends an event to a single channel by ID or name
+func (m *Manager) SendToChannel(channel string, event *Event) error {
+	record, err := m.findChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	return m.deliver(record, event, map[string]bool{record.Id: true})
+}
+
+// NotifyChannels queues an event for the enabled channels among the given
+// IDs or names
+func (m *Manager) NotifyChannels(channels []string, event *Event) {
+	recipients := make(map[string]bool)
+	var enabled []*models.Record
+	for _, channel := range channels {
+		record, err := m.findChannel(channel)
+		if err != nil {
+			log.Printf("Error sending notification: %v", err)
 			continue
 		}
+		if record.GetBool("enabled") && !recipients[record.Id] {
+			recipients[record.Id] = true
+			enabled = append(enabled, record)
+		}
+	}
+
+	for _, record := range enabled {
+		m.enqueue(record, event, recipients)
+	}
+}
+
+// enqueue adds an event to the queue of a channel, starting its worker on
+// first use. Events are dropped while the queue is full.
+func (m *Manager) enqueue(record *models.Record, event *Event, recipients map[string]bool) {
+	// Workers share the event, so it must not change once queued
+	if event.Host == "" {
+		event.Host = m.hub.Host()
+	}
+
+	m.queuesMu.Lock()
+	queue, ok := m.queues[record.Id]
+	if !ok {
+		queue = make(chan delivery, queueSize)
+		m.queues[record.Id] = queue
+		go m.work(queue)
+	}
+	m.queuesMu.Unlock()
 
-		url := record.GetString("url")
-		name := record.GetString("name")
+	select {
+	case queue <- delivery{record: record, event: event, recipients: recipients}:
+		metrics.NotificationQueueDepth.Inc()
+	default:
+		metrics.NotificationsFailed.WithLabelValues(record.GetString("name")).Inc()
+		log.Printf("Notification queue of %s is full, dropping %s event", record.GetString("name"), event.Type)
+	}
+}
 
-		if err := m.SendToURL(url, message); err != nil {
-			log.Printf("Error sending notification to %s: %v", name, err)
-		} else {
-			log.Printf("✅ Notification sent to %s", name)
+// work delivers the queued events of a channel in order until stopped
+func (m *Manager) work(queue chan delivery) {
+	for {
+		select {
+		case d := <-queue:
+			m.deliver(d.record, d.event, d.recipients)
+			metrics.NotificationQueueDepth.Dec()
+		case <-m.ctx.Done():
+			return
 		}
 	}
 }
 
-// SendToURL sends a notification to a specific URL
-func (m *Manager) SendToURL(url, message string) error {
-	sender, err := shoutrrr.CreateSender(url)
+// actionLinks returns signed links to act on a failing container
+func (m *Manager) actionLinks(event *Event) []Link {
+	req := actions.Request{
+		Container: event.Container.ID,
+		Name:      event.Container.Name,
+	}
+
+	var links []Link
+	if event.IncidentID != "" {
+		ack := req
+		ack.Action, ack.Incident = actions.Acknowledge, event.IncidentID
+		links = append(links, Link{Title: "Acknowledge", URL: m.actions.URL(ack)})
+	}
+
+	mute := req
+	mute.Action, mute.Duration = actions.Mute, time.Hour
+	links = append(links, Link{Title: "Mute 1h", URL: m.actions.URL(mute)})
+
+	if m.restart {
+		restart := req
+		restart.Action = actions.Restart
+		links = append(links, Link{Title: "Restart container", URL: m.actions.URL(restart)})
+	}
+
+	return links
+}
+
+// findChannel looks up a notification channel by ID or name
+func (m *Manager) findChannel(channel string) (*models.Record, error) {
+	record, err := m.db.App().Dao().FindRecordById("notifications", channel)
+	if err != nil {
+		record, err = m.db.App().Dao().FindFirstRecordByData("notifications", "name", channel)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %q not found", channel)
+		}
+	}
+	return record, nil
+}
+
+// deliver sends an event to a channel and records the outcome. Events held
+// for the channel's quiet hours are sent later in a summary, and channels
+// that keep failing are reported to their fallback channel.
+func (m *Manager) deliver(record *models.Record, event *Event, recipients map[string]bool) error {
+	if event.Host == "" {
+		event.Host = m.hub.Host()
+	}
+	if m.hold(record, event) {
+		return nil
+	}
+
+	err := m.transmit(record, event)
+	previous, health := m.updateHealth(record, err)
+	m.failover(record, event, err, previous, health, recipients)
+	return err
+}
+
+// transmit sends an event to a channel and records the delivery
+func (m *Manager) transmit(record *models.Record, event *Event) error {
+	name := record.GetString("name")
+
+	started := time.Now()
+	err := m.send(record, event)
+	duration := time.Since(started)
+	metrics.DeliveryDuration.WithLabelValues(name).Observe(duration.Seconds())
+
+	result := map[string]any{
+		"channel_id": record.Id,
+		"channel":    name,
+		"message":    event.Message,
+		"success":    err == nil,
+	}
+
+	if err != nil {
+		metrics.NotificationsFailed.WithLabelValues(name).Inc()
+		log.Printf("Error sending notification to %s: %v", name, err)
+		result["error"] = err.Error()
+	} else {
+		metrics.NotificationsSent.WithLabelValues(name).Inc()
+		log.Printf("✅ Notification sent to %s", name)
+	}
+
+	m.recordDelivery(record, event.Message, err, duration)
+	m.hub.Publish(hub.Message{Type: hub.TypeDelivery, Data: result})
+
+	return err
+}
+
+// send sends an event using the transport of the channel type
+func (m *Manager) send(record *models.Record, event *Event) error {
+	url := record.GetString("url")
+
+	if record.GetString("type") == TypeWebhook {
+		config, err := ChannelWebhookConfig(record)
+		if err != nil {
+			return err
+		}
+		return m.SendWebhook(url, config, event)
+	}
+
+	return m.SendRich(url, event)
+}
+
+// ChannelWebhookConfig reads the webhook configuration of a channel
+func ChannelWebhookConfig(record *models.Record) (WebhookConfig, error) {
+	var config WebhookConfig
+	if err := record.UnmarshalJSONField("config", &config); err != nil {
+		return config, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	return config, nil
+}
+
+// recordDelivery stores the outcome of a delivery for statistics
+func (m *Manager) recordDelivery(channel *models.Record, message string, sendErr error, duration time.Duration) {
+	collection, err := m.db.App().Dao().FindCollectionByNameOrId("deliveries")
 	if err != nil {
-		return fmt.Errorf("failed to create sender: %w", err)
+		log.Printf("Error finding deliveries collection: %v", err)
+		return
+	}
+
+	record := models.NewRecord(collection)
+	record.Set("channel_id", channel.Id)
+	record.Set("channel_name", channel.GetString("name"))
+	record.Set("message", message)
+	record.Set("success", sendErr == nil)
+	record.Set("duration_ms", duration.Milliseconds())
+	record.Set("timestamp", time.Now())
+	if sendErr != nil {
+		record.Set("error", sendErr.Error())
 	}
 
-	errs := sender.Send(message, nil)
-	if len(errs) > 0 {
-		return fmt.Errorf("send errors: %v", errs)
+	if err := m.db.App().Dao().SaveRecord(record); err != nil {
+		log.Printf("Error saving delivery: %v", err)
 	}
+}
 
-	return nil
+// SendToURL sends a notification to a specific URL
+func (m *Manager) SendToURL(url, message string) error {
+	return sendWithParams(url, message, nil)
 }
 
+// testMessage is sent when testing a notification channel
+const testMessage = "🔔 Test notification from NotifyPipe! If you receive this, your notification channel is configured correctly."
+
 // TestNotification tests a notification URL
 func (m *Manager) TestNotification(url string) error {
-	testMessage := "🔔 Test notification from NotifyPipe! If you receive this, your notification channel is configured correctly."
 	return m.SendToURL(url, testMessage)
 }
+
+// TestChannel sends a test message to a saved channel right away, ignoring
+// its quiet hours, and stores the outcome on the channel
+func (m *Manager) TestChannel(record *models.Record, message string) error {
+	if message == "" {
+		message = testMessage
+	}
+	event := NewEvent("test", "", message)
+	event.Title = "Test notification"
+	event.Host = m.hub.Host()
+
+	sendErr := m.transmit(record, event)
+	m.updateHealth(record, sendErr)
+
+	lastError := ""
+	if sendErr != nil {
+		lastError = sendErr.Error()
+	}
+	_, err := m.db.App().Dao().DB().Update("notifications", dbx.Params{
+		"last_tested":       pbtypes.NowDateTime().String(),
+		"last_test_success": sendErr == nil,
+		"last_test_error":   lastError,
+	}, dbx.HashExp{"id": record.Id}).Execute()
+	if err != nil {
+		log.Printf("Error saving test result of %s: %v", record.GetString("name"), err)
+	}
+
+	return sendErr
+}
+
+// TestWebhook sends a test event to a webhook URL
+func (m *Manager) TestWebhook(url string, config WebhookConfig) error {
+	event := NewEvent("test", "", testMessage)
+	event.Host = m.hub.Host()
+	return m.SendWebhook(url, config, event)
+}
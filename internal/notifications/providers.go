@@ -0,0 +1,199 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containrrr/shoutrrr"
+	"github.com/containrrr/shoutrrr/pkg/services/discord"
+	"github.com/containrrr/shoutrrr/pkg/services/slack"
+	"github.com/containrrr/shoutrrr/pkg/services/teams"
+	"github.com/containrrr/shoutrrr/pkg/types"
+)
+
+// slackPostMessage is the Slack API endpoint used with bot tokens
+const slackPostMessage = "https://slack.com/api/chat.postMessage"
+
+// providerClient is used for requests made without Shoutrrr
+var providerClient = &http.Client{Timeout: 30 * time.Second}
+
+// SendRich sends an event to a Shoutrrr URL in the richest format the
+// service supports. Slack and Teams payloads are posted directly because
+// Shoutrrr cannot send blocks or cards; other services go through Shoutrrr.
+func (m *Manager) SendRich(rawURL string, event *Event) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to create sender: %w", err)
+	}
+
+	switch u.Scheme {
+	case "slack":
+		return sendSlack(u, event)
+	case "teams":
+		return sendTeams(u, event)
+	case "discord":
+		return sendDiscord(u, event)
+	case "telegram":
+		return sendWithParams(rawURL, telegramHTML(event), types.Params{
+			"parsemode": "HTML",
+			"title":     event.Title,
+		})
+	case "matrix":
+		return m.SendToURL(rawURL, matrixText(event))
+	}
+
+	return m.SendToURL(rawURL, PlainText(event))
+}
+
+// sendSlack posts Block Kit to a Slack webhook or, with a bot token, the API
+func sendSlack(u *url.URL, event *Event) error {
+	config := &slack.Config{}
+	if err := config.SetURL(u); err != nil {
+		return fmt.Errorf("failed to create sender: %w", err)
+	}
+
+	payload := slackPayload(event)
+	if config.BotName != "" {
+		payload["username"] = config.BotName
+	}
+	if config.Icon != "" {
+		if strings.HasPrefix(config.Icon, "http://") || strings.HasPrefix(config.Icon, "https://") {
+			payload["icon_url"] = config.Icon
+		} else {
+			payload["icon_emoji"] = config.Icon
+		}
+	}
+	if config.ThreadTS != "" {
+		payload["thread_ts"] = config.ThreadTS
+	}
+
+	if !config.Token.IsAPIToken() {
+		_, err := postJSON(config.Token.WebhookURL(), nil, payload)
+		return err
+	}
+
+	payload["channel"] = config.Channel
+	body, err := postJSON(slackPostMessage, map[string]string{"Authorization": config.Token.Authorization()}, payload)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("invalid slack response: %w", err)
+	}
+	if !response.Ok {
+		return fmt.Errorf("slack api error: %s", response.Error)
+	}
+	return nil
+}
+
+// sendTeams posts an adaptive card to a Teams incoming webhook
+func sendTeams(u *url.URL, event *Event) error {
+	config := &teams.Config{}
+	if err := config.SetURL(u); err != nil {
+		return fmt.Errorf("failed to create sender: %w", err)
+	}
+
+	host, path := config.Host, teams.Path
+	if host == "" {
+		host = teams.LegacyHost
+	}
+	if host == teams.LegacyHost {
+		path = teams.LegacyPath
+	}
+	webhookURL := fmt.Sprintf("https://%s/%s/%s@%s/%s/%s/%s",
+		host, path, config.Group, config.Tenant, teams.ProviderName, config.AltID, config.GroupOwner)
+
+	_, err := postJSON(webhookURL, nil, teamsPayload(event))
+	return err
+}
+
+// sendDiscord sends an embed through Shoutrrr's raw JSON mode
+func sendDiscord(u *url.URL, event *Event) error {
+	config := &discord.Config{}
+	if err := config.SetURL(u); err != nil {
+		return fmt.Errorf("failed to create sender: %w", err)
+	}
+
+	payload := discordPayload(event)
+	if config.Username != "" {
+		payload["username"] = config.Username
+	}
+	if config.Avatar != "" {
+		payload["avatar_url"] = config.Avatar
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	query.Set("json", "yes")
+	jsonURL := *u
+	jsonURL.RawQuery = query.Encode()
+
+	return sendWithParams(jsonURL.String(), string(body), nil)
+}
+
+// sendWithParams sends a message through Shoutrrr with service params
+func sendWithParams(rawURL, message string, params types.Params) error {
+	sender, err := shoutrrr.CreateSender(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to create sender: %w", err)
+	}
+
+	var p *types.Params
+	if params != nil {
+		p = &params
+	}
+
+	errs := sender.Send(message, p)
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("send errors: %v", errs)
+		}
+	}
+
+	return nil
+}
+
+// postJSON posts a JSON payload and returns the response body
+func postJSON(url string, headers map[string]string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := providerClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("request failed with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
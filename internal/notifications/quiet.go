@@ -0,0 +1,262 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// summaryInterval is how often channels whose quiet hours ended get their
+// summary of held events
+const summaryInterval = time.Minute
+
+// maxSummaryLines is how many held events a summary lists
+const maxSummaryLines = 50
+
+// QuietHours is a daily window in a channel's timezone during which events
+// below a severity are held and sent as one summary once the window ends.
+// Start and end are "HH:MM"; a window ending before it starts spans
+// midnight.
+type QuietHours struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// Validate checks the window and severity of quiet hours
+func (q QuietHours) Validate() error {
+	for _, value := range []string{q.Start, q.End} {
+		if _, err := clockMinutes(value); err != nil {
+			return err
+		}
+	}
+	if q.Severity != "" && !q.Severity.Valid() {
+		return fmt.Errorf("unknown severity %q", q.Severity)
+	}
+	return nil
+}
+
+// Active reports whether a time falls within the quiet hours
+func (q QuietHours) Active(now time.Time) bool {
+	start, err := clockMinutes(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := clockMinutes(q.End)
+	if err != nil || start == end {
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// passes reports whether an event is important enough to be delivered during
+// quiet hours. Without a severity only errors pass.
+func (q QuietHours) passes(event *Event) bool {
+	severity := q.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+	return event.Severity.AtLeast(severity)
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight
+func clockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidTimezone checks that a timezone name is known
+func ValidTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	return nil
+}
+
+// ChannelLocation returns the timezone of a channel, or the server's local
+// timezone if it has none
+func ChannelLocation(record *models.Record) *time.Location {
+	if name := record.GetString("timezone"); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	return time.Local
+}
+
+// ChannelQuietHours reads the quiet hours of a channel, if it has any
+func ChannelQuietHours(record *models.Record) (*QuietHours, error) {
+	if raw := record.GetString("quiet_hours"); raw == "" || raw == "null" {
+		return nil, nil
+	}
+
+	var quiet *QuietHours
+	if err := record.UnmarshalJSONField("quiet_hours", &quiet); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours: %w", err)
+	}
+	if quiet == nil || quiet.Start == "" {
+		return nil, nil
+	}
+	return quiet, nil
+}
+
+// hold stores an event for the summary of a channel if the channel is in its
+// quiet hours and the event is not important enough to pass
+func (m *Manager) hold(record *models.Record, event *Event) bool {
+	quiet, err := ChannelQuietHours(record)
+	if err != nil {
+		log.Printf("Error reading quiet hours of %s: %v", record.GetString("name"), err)
+		return false
+	}
+	if quiet == nil || quiet.passes(event) || !quiet.Active(time.Now().In(ChannelLocation(record))) {
+		return false
+	}
+
+	collection, err := m.db.App().Dao().FindCollectionByNameOrId("held_notifications")
+	if err != nil {
+		log.Printf("Error finding held_notifications collection: %v", err)
+		return false
+	}
+
+	held := models.NewRecord(collection)
+	held.Set("channel_id", record.Id)
+	held.Set("event", event)
+	held.Set("timestamp", event.Timestamp)
+	if err := m.db.App().Dao().SaveRecord(held); err != nil {
+		log.Printf("Error holding notification: %v", err)
+		return false
+	}
+
+	log.Printf("🌙 Holding notification for %s until its quiet hours end", record.GetString("name"))
+	return true
+}
+
+// Start sends the summaries of held events until stopped
+func (m *Manager) Start() {
+	ticker := time.NewTicker(summaryInterval)
+	defer ticker.Stop()
+
+	for {
+		m.sendSummaries()
+
+		select {
+		case <-ticker.C:
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops sending summaries
+func (m *Manager) Stop() {
+	m.cancelFunc()
+}
+
+// sendSummaries sends held events to each channel whose quiet hours are over.
+// Held events are only removed once their summary was delivered.
+func (m *Manager) sendSummaries() {
+	held, err := m.db.App().Dao().FindRecordsByFilter("held_notifications", database.MatchAll, "timestamp", 0, 0)
+	if err != nil {
+		log.Printf("Error finding held notifications: %v", err)
+		return
+	}
+
+	byChannel := make(map[string][]*models.Record)
+	var channels []string
+	for _, record := range held {
+		id := record.GetString("channel_id")
+		if _, ok := byChannel[id]; !ok {
+			channels = append(channels, id)
+		}
+		byChannel[id] = append(byChannel[id], record)
+	}
+
+	for _, id := range channels {
+		channel, err := m.db.App().Dao().FindRecordById("notifications", id)
+		if err != nil {
+			// The channel was deleted, so its events have nowhere to go
+			m.deleteHeld(byChannel[id])
+			continue
+		}
+
+		location := ChannelLocation(channel)
+		if quiet, _ := ChannelQuietHours(channel); quiet != nil && quiet.Active(time.Now().In(location)) {
+			continue
+		}
+
+		if channel.GetBool("enabled") {
+			// Keep the events for the next attempt if the summary fails
+			if err := m.deliver(channel, summaryEvent(byChannel[id], location), map[string]bool{channel.Id: true}); err != nil {
+				continue
+			}
+		}
+		m.deleteHeld(byChannel[id])
+	}
+}
+
+// summaryEvent lists held events in one message
+func summaryEvent(held []*models.Record, location *time.Location) *Event {
+	counts := make(map[Severity]int)
+	var lines []string
+
+	for i, record := range held {
+		var event Event
+		if err := record.UnmarshalJSONField("event", &event); err != nil {
+			continue
+		}
+		counts[event.Severity]++
+
+		if i < maxSummaryLines {
+			message := event.Message
+			if event.Title != "" {
+				message = event.Title
+			}
+			lines = append(lines, fmt.Sprintf("• %s %s", event.Timestamp.In(location).Format("15:04"), message))
+		}
+	}
+	if len(held) > maxSummaryLines {
+		lines = append(lines, fmt.Sprintf("… and %d more", len(held)-maxSummaryLines))
+	}
+
+	event := NewEvent("summary", "", strings.Join(lines, "\n"))
+	event.Title = fmt.Sprintf("🌅 %d notification(s) held during quiet hours", len(held))
+	for _, field := range []struct {
+		name     string
+		severity Severity
+	}{
+		{"Warnings", SeverityWarning},
+		{"Successes", SeveritySuccess},
+		{"Info", SeverityInfo},
+	} {
+		if counts[field.severity] > 0 {
+			event.AddField(field.name, fmt.Sprint(counts[field.severity]), true)
+		}
+	}
+	return event
+}
+
+// deleteHeld removes held events once they were summarized
+func (m *Manager) deleteHeld(records []*models.Record) {
+	ids := make([]any, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.Id)
+	}
+
+	_, err := m.db.App().Dao().DB().Delete("held_notifications", dbx.In("id", ids...)).Execute()
+	if err != nil {
+		log.Printf("Error deleting held notifications: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	tests := []struct {
+		start, end string
+		clock      string
+		want       bool
+	}{
+		{"22:00", "07:00", "23:30", true},
+		{"22:00", "07:00", "00:00", true},
+		{"22:00", "07:00", "06:59", true},
+		{"22:00", "07:00", "07:00", false},
+		{"22:00", "07:00", "21:59", false},
+		{"22:00", "07:00", "22:00", true},
+		{"12:00", "14:00", "13:00", true},
+		{"12:00", "14:00", "14:00", false},
+		{"12:00", "14:00", "11:59", false},
+		{"09:00", "09:00", "09:00", false},
+		{"9pm", "07:00", "23:00", false},
+	}
+
+	for _, tt := range tests {
+		clock, err := time.Parse("15:04", tt.clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Date(2025, 3, 1, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+
+		quiet := QuietHours{Start: tt.start, End: tt.end}
+		if got := quiet.Active(now); got != tt.want {
+			t.Errorf("QuietHours{%s, %s}.Active(%s) = %v, want %v", tt.start, tt.end, tt.clock, got, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	tests := []struct {
+		quiet   QuietHours
+		wantErr bool
+	}{
+		{QuietHours{Start: "22:00", End: "07:00"}, false},
+		{QuietHours{Start: "22:00", End: "07:00", Severity: SeverityWarning}, false},
+		{QuietHours{Start: "24:00", End: "07:00"}, true},
+		{QuietHours{Start: "22:00", End: ""}, true},
+		{QuietHours{Start: "22:00", End: "07:00", Severity: "urgent"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.quiet.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, want error %v", tt.quiet, err, tt.wantErr)
+		}
+	}
+}
+
+func TestQuietHoursPasses(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		event    Severity
+		want     bool
+	}{
+		{"", SeverityError, true},
+		{"", SeverityWarning, false},
+		{SeverityWarning, SeverityWarning, true},
+		{SeverityWarning, SeveritySuccess, false},
+	}
+
+	for _, tt := range tests {
+		event := &Event{Severity: tt.event}
+		if got := (QuietHours{Severity: tt.severity}).passes(event); got != tt.want {
+			t.Errorf("QuietHours{Severity: %q}.passes(%s) = %v, want %v", tt.severity, tt.event, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package notifications
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLogLength limits how much of the logs are included in a message
+const maxLogLength = 1500
+
+// PlainText renders an event as text for providers without rich formatting.
+// Events without a title or details render as their message alone.
+func PlainText(e *Event) string {
+	var b strings.Builder
+
+	if e.Title != "" {
+		b.WriteString(e.Title)
+		b.WriteString("\n")
+	}
+	b.WriteString(e.Message)
+
+	if len(e.Fields) > 0 {
+		b.WriteString("\n")
+		for _, field := range e.Fields {
+			fmt.Fprintf(&b, "\n%s: %s", field.Name, field.Value)
+		}
+	}
+
+	if e.Logs != "" {
+		fmt.Fprintf(&b, "\n\nLogs:\n%s", truncateLogs(e.Logs))
+	}
+
+	if len(e.Links) > 0 {
+		b.WriteString("\n")
+		for _, link := range e.Links {
+			fmt.Fprintf(&b, "\n%s: %s", link.Title, link.URL)
+		}
+	}
+
+	return b.String()
+}
+
+// slackPayload renders an event as Slack Block Kit inside a colored
+// attachment. Slack shows the top level text above the attachment, so it
+// carries the title, or the message if there is none.
+func slackPayload(e *Event) map[string]any {
+	var blocks []any
+
+	text := slackEscape(e.Message)
+	if e.Title != "" {
+		text = "*" + slackEscape(e.Title) + "*"
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": slackEscape(e.Message)},
+		})
+	}
+
+	if len(e.Fields) > 0 {
+		var fields []any
+		for i, field := range e.Fields {
+			// Slack allows at most ten fields per section
+			if i == 10 {
+				break
+			}
+			fields = append(fields, map[string]any{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", slackEscape(field.Name), slackEscape(field.Value)),
+			})
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+
+	if e.Logs != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": "```" + truncateLogs(e.Logs) + "```"},
+		})
+	}
+
+	if len(e.Links) > 0 {
+		var buttons []any
+		for _, link := range e.Links {
+			buttons = append(buttons, map[string]any{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": link.Title},
+				"url":  link.URL,
+			})
+		}
+		blocks = append(blocks, map[string]any{"type": "actions", "elements": buttons})
+	}
+
+	if context := footer(e); context != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []any{map[string]any{"type": "mrkdwn", "text": slackEscape(context)}},
+		})
+	}
+
+	return map[string]any{
+		"text": text,
+		"attachments": []any{map[string]any{
+			"color":  e.Severity.Color(),
+			"blocks": blocks,
+		}},
+	}
+}
+
+// discordPayload renders an event as a Discord embed
+func discordPayload(e *Event) map[string]any {
+	description := e.Message
+	for _, link := range e.Links {
+		description += fmt.Sprintf("\n[%s](%s)", link.Title, link.URL)
+	}
+	if e.Logs != "" {
+		description += "\n```\n" + truncateLogs(e.Logs) + "\n```"
+	}
+
+	embed := map[string]any{
+		"title":       e.Title,
+		"description": description,
+		"color":       colorValue(e.Severity.Color()),
+		"timestamp":   e.Timestamp.Format(time.RFC3339),
+	}
+
+	var fields []any
+	for i, field := range e.Fields {
+		// Discord allows at most 25 fields per embed
+		if i == 25 {
+			break
+		}
+		fields = append(fields, map[string]any{
+			"name":   field.Name,
+			"value":  field.Value,
+			"inline": field.Inline,
+		})
+	}
+	if len(fields) > 0 {
+		embed["fields"] = fields
+	}
+
+	if e.Host != "" {
+		embed["footer"] = map[string]any{"text": e.Host}
+	}
+
+	return map[string]any{"embeds": []any{embed}}
+}
+
+// teamsPayload renders an event as a Microsoft Teams adaptive card
+func teamsPayload(e *Event) map[string]any {
+	var body []any
+
+	if e.Title != "" {
+		body = append(body, map[string]any{
+			"type":   "TextBlock",
+			"text":   e.Title,
+			"size":   "Large",
+			"weight": "Bolder",
+			"color":  teamsColor(e.Severity),
+			"wrap":   true,
+		})
+	}
+
+	body = append(body, map[string]any{
+		"type": "TextBlock",
+		"text": e.Message,
+		"wrap": true,
+	})
+
+	if len(e.Fields) > 0 {
+		var facts []any
+		for _, field := range e.Fields {
+			facts = append(facts, map[string]any{"title": field.Name, "value": field.Value})
+		}
+		body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+	}
+
+	if e.Logs != "" {
+		body = append(body, map[string]any{
+			"type":     "TextBlock",
+			"text":     truncateLogs(e.Logs),
+			"fontType": "Monospace",
+			"wrap":     true,
+		})
+	}
+
+	if context := footer(e); context != "" {
+		body = append(body, map[string]any{
+			"type":     "TextBlock",
+			"text":     context,
+			"isSubtle": true,
+			"size":     "Small",
+			"wrap":     true,
+		})
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+
+	if len(e.Links) > 0 {
+		var actions []any
+		for _, link := range e.Links {
+			actions = append(actions, map[string]any{
+				"type":  "Action.OpenUrl",
+				"title": link.Title,
+				"url":   link.URL,
+			})
+		}
+		card["actions"] = actions
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
+
+// telegramHTML renders an event body for Telegram's HTML parse mode. The
+// title is sent separately as a Shoutrrr param.
+func telegramHTML(e *Event) string {
+	var b strings.Builder
+
+	b.WriteString(html.EscapeString(e.Message))
+
+	if len(e.Fields) > 0 {
+		b.WriteString("\n")
+		for _, field := range e.Fields {
+			fmt.Fprintf(&b, "\n<b>%s:</b> %s", html.EscapeString(field.Name), html.EscapeString(field.Value))
+		}
+	}
+
+	if e.Logs != "" {
+		fmt.Fprintf(&b, "\n\n<pre>%s</pre>", html.EscapeString(truncateLogs(e.Logs)))
+	}
+
+	if len(e.Links) > 0 {
+		b.WriteString("\n")
+		for _, link := range e.Links {
+			fmt.Fprintf(&b, "\n<a href=\"%s\">%s</a>", html.EscapeString(link.URL), html.EscapeString(link.Title))
+		}
+	}
+
+	return b.String()
+}
+
+// footer describes where and when an event happened
+func footer(e *Event) string {
+	parts := []string{}
+	if e.Host != "" {
+		parts = append(parts, e.Host)
+	}
+	if !e.Timestamp.IsZero() {
+		parts = append(parts, e.Timestamp.Format(time.RFC1123))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// truncateLogs keeps the end of long logs, which is usually the interesting part
+func truncateLogs(logs string) string {
+	if len(logs) <= maxLogLength {
+		return logs
+	}
+	logs = logs[len(logs)-maxLogLength:]
+	if i := strings.IndexByte(logs, '\n'); i >= 0 {
+		logs = logs[i+1:]
+	}
+	return "…\n" + logs
+}
+
+// slackEscape escapes the characters Slack treats as control sequences
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// colorValue converts a #rrggbb color to an integer
+func colorValue(color string) int {
+	n, _ := strconv.ParseInt(strings.TrimPrefix(color, "#"), 16, 32)
+	return int(n)
+}
+
+// teamsColor maps a severity to an adaptive card color
+func teamsColor(s Severity) string {
+	switch s {
+	case SeveritySuccess:
+		return "Good"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityError:
+		return "Attention"
+	}
+	return "Accent"
+}
+
+// matrixText renders an event for Matrix, which Shoutrrr sends as plain
+// text, marking the severity with an emoji
+func matrixText(e *Event) string {
+	if e.Title == "" {
+		return PlainText(e)
+	}
+
+	marker := map[Severity]string{
+		SeveritySuccess: "🟢",
+		SeverityWarning: "🟡",
+		SeverityError:   "🔴",
+	}[e.Severity]
+	if marker == "" {
+		marker = "🔵"
+	}
+
+	titled := *e
+	titled.Title = marker + " " + e.Title
+	return PlainText(&titled)
+}
@@ -0,0 +1,182 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containrrr/shoutrrr/pkg/format"
+	"github.com/containrrr/shoutrrr/pkg/router"
+)
+
+// typeSchemes maps channel types to their Shoutrrr scheme where the two
+// differ
+var typeSchemes = map[string]string{
+	"email": "smtp",
+}
+
+// serviceRouter creates and locates Shoutrrr services
+var serviceRouter = &router.ServiceRouter{}
+
+// Service is a channel type with the fields of its URL
+type Service struct {
+	Type   string         `json:"type"`
+	Scheme string         `json:"scheme"`
+	Fields []ServiceField `json:"fields"`
+}
+
+// ServiceField describes a setting of a service. URLPart says where it goes
+// in the URL: user, password, host, port, path or query, where it is set
+// with one of its keys.
+type ServiceField struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Default     string   `json:"default,omitempty"`
+	URLPart     string   `json:"url_part"`
+	Keys        []string `json:"keys,omitempty"`
+	Values      []string `json:"values,omitempty"`
+}
+
+// SchemeForType returns the URL scheme a channel type uses
+func SchemeForType(channelType string) string {
+	if scheme, ok := typeSchemes[channelType]; ok {
+		return scheme
+	}
+	return channelType
+}
+
+// typeForScheme returns the channel type of a URL scheme
+func typeForScheme(scheme string) string {
+	for channelType, s := range typeSchemes {
+		if s == scheme {
+			return channelType
+		}
+	}
+	return scheme
+}
+
+// TypeForURL returns the channel type of a service URL's scheme
+func TypeForURL(rawURL string) string {
+	scheme, _, err := serviceRouter.ExtractServiceName(rawURL)
+	if err != nil {
+		return ""
+	}
+	return typeForScheme(scheme)
+}
+
+// Services lists the supported channel types and their fields
+func Services() []Service {
+	schemes := serviceRouter.ListServices()
+	sort.Strings(schemes)
+
+	services := make([]Service, 0, len(schemes)+1)
+	for _, scheme := range schemes {
+		service, err := serviceRouter.NewService(scheme)
+		if err != nil {
+			continue
+		}
+
+		var fields []ServiceField
+		for _, node := range format.GetServiceConfigFormat(service).Items {
+			fields = append(fields, serviceField(node.Field()))
+		}
+		services = append(services, Service{Type: typeForScheme(scheme), Scheme: scheme, Fields: fields})
+	}
+
+	services = append(services, Service{
+		Type:   TypeWebhook,
+		Scheme: "https",
+		Fields: []ServiceField{
+			{Name: "URL", Description: "Endpoint the event is posted to as JSON", Type: "string", Required: true, URLPart: "url"},
+			{Name: "Secret", Description: "Key of the HMAC-SHA256 signature header", Type: "string", URLPart: "config", Keys: []string{"secret"}},
+			{Name: "Headers", Description: "Extra request headers", Type: "map", URLPart: "config", Keys: []string{"headers"}},
+			{Name: "Retries", Description: "Retries after a failed attempt", Type: "int", URLPart: "config", Keys: []string{"retries"}},
+			{Name: "Timeout", Description: "Timeout of a single attempt", Type: "string", URLPart: "config", Keys: []string{"timeout"}},
+		},
+	})
+
+	sort.Slice(services, func(i, j int) bool { return services[i].Type < services[j].Type })
+	return services
+}
+
+// serviceField describes a Shoutrrr config field
+func serviceField(info *format.FieldInfo) ServiceField {
+	field := ServiceField{
+		Name:        info.Name,
+		Description: info.Description,
+		Type:        info.Type.Kind().String(),
+		Required:    info.Required,
+		Default:     info.DefaultValue,
+		URLPart:     "query",
+		Keys:        info.Keys,
+	}
+
+	if len(info.URLParts) > 0 {
+		part := info.URLParts[0]
+		if part > format.URLPath {
+			part = format.URLPath
+		}
+		field.URLPart = strings.ToLower(part.String())
+		if field.URLPart != "query" {
+			field.Keys = nil
+		}
+	}
+	if info.IsEnum() {
+		field.Type = "enum"
+		field.Values = info.EnumFormatter.Names()
+	}
+	if info.Type.Kind() == reflect.Slice || info.Type.Kind() == reflect.Array {
+		field.Type = "list"
+	}
+	return field
+}
+
+// ValidateChannel checks that a URL is valid for a channel type and returns
+// the problems found per field, or nil if there are none
+func ValidateChannel(channelType, rawURL string) map[string]string {
+	errs := make(map[string]string)
+	if channelType == "" {
+		errs["type"] = "Type is required"
+	}
+	if rawURL == "" {
+		errs["url"] = "URL is required"
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if channelType == TypeWebhook {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs["url"] = "Webhook URL must be an http or https URL"
+			return errs
+		}
+		return nil
+	}
+
+	scheme := SchemeForType(channelType)
+	if _, err := serviceRouter.NewService(scheme); err != nil {
+		errs["type"] = fmt.Sprintf("Unknown channel type %q", channelType)
+		return errs
+	}
+
+	urlScheme, _, err := serviceRouter.ExtractServiceName(rawURL)
+	if err != nil || urlScheme == "" {
+		errs["url"] = "URL is not a valid service URL"
+		return errs
+	}
+	if urlScheme != scheme {
+		errs["type"] = fmt.Sprintf("Type %q does not match the URL scheme %q", channelType, urlScheme)
+		return errs
+	}
+
+	if _, err := serviceRouter.Locate(rawURL); err != nil {
+		errs["url"] = err.Error()
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// TypeWebhook is the channel type for native JSON webhooks
+const TypeWebhook = "webhook"
+
+// Headers set on every webhook request
+const (
+	HeaderEvent     = "X-NotifyPipe-Event"
+	HeaderDelivery  = "X-NotifyPipe-Delivery"
+	HeaderTimestamp = "X-NotifyPipe-Timestamp"
+	HeaderSignature = "X-NotifyPipe-Signature"
+)
+
+// Webhook defaults and limits
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookTimeout = 10 * time.Second
+	maxWebhookRetries     = 5
+	maxWebhookTimeout     = 30 * time.Second
+	webhookRetryBackoff   = time.Second
+)
+
+// WebhookConfig is the configuration of a webhook channel
+type WebhookConfig struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Secret  string            `json:"secret,omitempty"`
+	Retries *int              `json:"retries,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+}
+
+// retries returns the number of retries after the first attempt
+func (c WebhookConfig) retries() int {
+	if c.Retries == nil || *c.Retries < 0 {
+		return defaultWebhookRetries
+	}
+	return min(*c.Retries, maxWebhookRetries)
+}
+
+// timeout returns the timeout of a single attempt
+func (c WebhookConfig) timeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return min(d, maxWebhookTimeout)
+	}
+	return defaultWebhookTimeout
+}
+
+// Validate checks the configuration for errors
+func (c WebhookConfig) Validate() error {
+	if c.Retries != nil && (*c.Retries < 0 || *c.Retries > maxWebhookRetries) {
+		return fmt.Errorf("retries must be between 0 and %d", maxWebhookRetries)
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", c.Timeout)
+		} else if d > maxWebhookTimeout {
+			return fmt.Errorf("timeout cannot be longer than %s", maxWebhookTimeout)
+		}
+	}
+	for name := range c.Headers {
+		if name == "" {
+			return fmt.Errorf("header names cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Sign computes the signature of a webhook body sent at the given Unix
+// timestamp. Receivers should recompute it and reject old timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SendWebhook posts an event as JSON to a URL, retrying server errors
+func (m *Manager) SendWebhook(url string, config WebhookConfig, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	client := &http.Client{Timeout: config.timeout()}
+	delivery := security.RandomString(20)
+	backoff := webhookRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := postWebhook(client, url, config, event.Type, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= config.retries() {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-m.ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook makes a single webhook request and reports whether a failure
+// is worth retrying
+func postWebhook(client *http.Client, url string, config WebhookConfig, eventType, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// The timestamp is signed with the body so it cannot be replayed later
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NotifyPipe")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, delivery)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if config.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(config.Secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
@@ -0,0 +1,69 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret, timestamp, body string
+		want                    string
+	}{
+		{"s3cret", "1700000000", `{"version":1}`, "sha256=a88b61052c4236878628e418fb04bb6048d23e65d5ad3c5daf2866200cb2994f"},
+		{"", "0", "", "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+
+	// The timestamp is part of the signature, so a replayed body fails
+	if Sign("s3cret", "1700000000", []byte("{}")) == Sign("s3cret", "1700000001", []byte("{}")) {
+		t.Error("Sign() does not depend on the timestamp")
+	}
+}
+
+func TestWebhookConfigValidate(t *testing.T) {
+	retries := func(n int) *int { return &n }
+	tests := []struct {
+		config  WebhookConfig
+		wantErr bool
+	}{
+		{WebhookConfig{}, false},
+		{WebhookConfig{Retries: retries(0), Timeout: "5s"}, false},
+		{WebhookConfig{Retries: retries(maxWebhookRetries), Timeout: maxWebhookTimeout.String()}, false},
+		{WebhookConfig{Retries: retries(maxWebhookRetries + 1)}, true},
+		{WebhookConfig{Retries: retries(-1)}, true},
+		{WebhookConfig{Timeout: "1m"}, true},
+		{WebhookConfig{Timeout: "0s"}, true},
+		{WebhookConfig{Timeout: "soon"}, true},
+		{WebhookConfig{Headers: map[string]string{"": "x"}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, want error %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookConfigLimits(t *testing.T) {
+	many := 100
+	config := WebhookConfig{Retries: &many, Timeout: "1h"}
+	if got := config.retries(); got != maxWebhookRetries {
+		t.Errorf("retries() = %d, want %d", got, maxWebhookRetries)
+	}
+	if got := config.timeout(); got != maxWebhookTimeout {
+		t.Errorf("timeout() = %s, want %s", got, maxWebhookTimeout)
+	}
+
+	if got := (WebhookConfig{}).timeout(); got != defaultWebhookTimeout {
+		t.Errorf("default timeout() = %s, want %s", got, defaultWebhookTimeout)
+	}
+	if got := (WebhookConfig{Timeout: "2s"}).timeout(); got != 2*time.Second {
+		t.Errorf("timeout() = %s, want 2s", got)
+	}
+}
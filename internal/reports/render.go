@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats a report can be rendered in
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// CSV renders one row per container
+func (r *Report) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{
+		"container",
+		"uptime_percent",
+		"availability_percent",
+		"incidents",
+		"monitored_seconds",
+		"uptime_seconds",
+		"downtime_seconds",
+		"unplanned_downtime_seconds",
+	})
+	for _, c := range r.Containers {
+		w.Write([]string{
+			c.Container,
+			formatFloat(c.UptimePercent, 3),
+			formatFloat(c.AvailabilityPercent, 3),
+			strconv.Itoa(c.Incidents),
+			formatFloat(c.MonitoredSeconds, 0),
+			formatFloat(c.UptimeSeconds, 0),
+			formatFloat(c.DowntimeSeconds, 0),
+			formatFloat(c.UnplannedSeconds, 0),
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Markdown renders a summary table followed by unplanned outages
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Uptime report\n\n")
+	fmt.Fprintf(&b, "%s – %s\n\n", r.Since.Format(time.RFC1123), r.Until.Format(time.RFC1123))
+
+	if len(r.Containers) == 0 {
+		b.WriteString("No container activity in this period.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Container | Uptime | Availability | Incidents | Downtime |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+	for _, c := range r.Containers {
+		fmt.Fprintf(&b, "| %s | %s%% | %s%% | %d | %s |\n",
+			escapeMarkdown(c.Container),
+			formatFloat(c.UptimePercent, 3),
+			formatFloat(c.AvailabilityPercent, 3),
+			c.Incidents,
+			formatSeconds(c.DowntimeSeconds),
+		)
+	}
+
+	for _, c := range r.Containers {
+		var outages []Downtime
+		for _, d := range c.DowntimeIntervals {
+			if !d.Planned {
+				outages = append(outages, d)
+			}
+		}
+		if len(outages) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", escapeMarkdown(c.Container))
+		for _, d := range outages {
+			fmt.Fprintf(&b, "- %s for %s: %s\n",
+				d.Start.Format(time.RFC1123),
+				formatSeconds(d.DurationSeconds),
+				escapeMarkdown(d.Reason),
+			)
+		}
+	}
+
+	return b.String()
+}
+
+// htmlTemplate renders the report as a standalone HTML document
+var htmlTemplate = template.Must(template.New("uptime").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return formatFloat(v, 3) + "%" },
+	"seconds": formatSeconds,
+	"date":    func(t time.Time) string { return t.Format(time.RFC1123) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Uptime report</title>
+</head>
+<body style="font-family: sans-serif">
+<h1>Uptime report</h1>
+<p>{{date .Since}} – {{date .Until}}</p>
+{{if .Containers}}
+<table border="1" cellpadding="6" cellspacing="0">
+<tr><th>Container</th><th>Uptime</th><th>Availability</th><th>Incidents</th><th>Downtime</th></tr>
+{{range .Containers}}
+<tr><td>{{.Container}}</td><td>{{percent .UptimePercent}}</td><td>{{percent .AvailabilityPercent}}</td><td>{{.Incidents}}</td><td>{{seconds .DowntimeSeconds}}</td></tr>
+{{end}}
+</table>
+{{range .Containers}}{{$name := .Container}}{{range .DowntimeIntervals}}{{if not .Planned}}
+<p><strong>{{$name}}</strong>: down {{date .Start}} for {{seconds .DurationSeconds}} ({{.Reason}})</p>
+{{end}}{{end}}{{end}}
+{{else}}
+<p>No container activity in this period.</p>
+{{end}}
+<p style="color: #888">Generated by NotifyPipe at {{date .GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+// HTML renders the report as a standalone HTML document
+func (r *Report) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Render renders the report as text in a format suited to a notification
+func (r *Report) Render(format string) (string, error) {
+	switch format {
+	case FormatCSV:
+		data, err := r.CSV()
+		return string(data), err
+	case FormatHTML:
+		return r.HTML()
+	case FormatMarkdown, "":
+		return r.Markdown(), nil
+	}
+	return "", fmt.Errorf("unsupported format %q", format)
+}
+
+// formatFloat formats a number with a fixed precision
+func formatFloat(v float64, precision int) string {
+	return strconv.FormatFloat(v, 'f', precision, 64)
+}
+
+// formatSeconds formats a number of seconds as a rounded duration
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// escapeMarkdown escapes characters that would break a table cell
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
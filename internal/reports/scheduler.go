@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/fatlirmorina/notifypipe/internal/notifications"
+)
+
+// Report schedules
+const (
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// lastPeriodKey is the settings key holding the last period that was sent
+const lastPeriodKey = "uptime_report_last_period"
+
+// checkInterval is how often the scheduler checks whether a report is due
+const checkInterval = time.Hour
+
+// Scheduler sends uptime reports for the previous week or month
+type Scheduler struct {
+	db         *database.Database
+	notifier   *notifications.Manager
+	schedule   string
+	channels   []string
+	format     string
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// NewScheduler creates a new report scheduler from the configuration
+func NewScheduler(db *database.Database, notifier *notifications.Manager, cfg *config.Config) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:         db,
+		notifier:   notifier,
+		schedule:   cfg.ReportSchedule,
+		channels:   cfg.ReportChannels,
+		format:     cfg.ReportFormat,
+		ctx:        ctx,
+		cancelFunc: cancel,
+	}
+}
+
+// Start checks for due reports until stopped
+func (s *Scheduler) Start() {
+	if s.schedule == "" || len(s.channels) == 0 {
+		log.Println("Scheduled uptime reports are disabled")
+		return
+	}
+	if s.schedule != ScheduleWeekly && s.schedule != ScheduleMonthly {
+		log.Printf("Unknown report schedule %q, scheduled uptime reports are disabled", s.schedule)
+		return
+	}
+
+	log.Printf("📅 Sending %s uptime reports to %d channel(s)", s.schedule, len(s.channels))
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runScheduled()
+
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	s.cancelFunc()
+}
+
+// runScheduled sends the report for the previous period if not sent yet
+func (s *Scheduler) runScheduled() {
+	since, until, period := PreviousPeriod(s.schedule, time.Now())
+	if s.db.GetSetting(lastPeriodKey) == period {
+		return
+	}
+
+	report, err := Uptime(s.db, since, until, "")
+	if err != nil {
+		log.Printf("Error building uptime report: %v", err)
+		return
+	}
+
+	message, err := report.Render(s.format)
+	if err != nil {
+		log.Printf("Error rendering uptime report: %v", err)
+		return
+	}
+
+	sent := 0
+	for _, channel := range s.channels {
+		if err := s.notifier.SendToChannel(channel, notifications.NewEvent("report", "", message)); err != nil {
+			log.Printf("Error sending uptime report to %s: %v", channel, err)
+			continue
+		}
+		sent++
+	}
+
+	// Retry on the next check if no channel received the report
+	if sent == 0 {
+		return
+	}
+
+	if err := s.db.SetSetting(lastPeriodKey, period); err != nil {
+		log.Printf("Error saving uptime report period: %v", err)
+	}
+	log.Printf("📅 Sent uptime report for %s", period)
+}
+
+// PreviousPeriod returns the last complete week (Monday to Monday) or
+// calendar month in UTC before now, with a key identifying it
+func PreviousPeriod(schedule string, now time.Time) (time.Time, time.Time, string) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if schedule == ScheduleWeekly {
+		weekday := (int(today.Weekday()) + 6) % 7 // days since Monday
+		until := today.AddDate(0, 0, -weekday)
+		since := until.AddDate(0, 0, -7)
+		year, week := since.ISOWeek()
+		return since, until, fmt.Sprintf("%d-W%02d", year, week)
+	}
+
+	until := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, -1, 0)
+	return since, until, since.Format("2006-01")
+}
@@ -0,0 +1,47 @@
+package reports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	tests := []struct {
+		schedule string
+		now      string
+		since    string
+		until    string
+		key      string
+	}{
+		{ScheduleWeekly, "2025-03-05T12:00:00Z", "2025-02-24", "2025-03-03", "2025-W09"},
+		{ScheduleWeekly, "2025-03-03T00:00:00Z", "2025-02-24", "2025-03-03", "2025-W09"},
+		{ScheduleWeekly, "2025-03-09T23:59:59Z", "2025-02-24", "2025-03-03", "2025-W09"},
+		{ScheduleWeekly, "2025-01-01T08:00:00Z", "2024-12-23", "2024-12-30", "2024-W52"},
+		{ScheduleWeekly, "2025-01-07T08:00:00Z", "2024-12-30", "2025-01-06", "2025-W01"},
+		{ScheduleWeekly, "2021-01-04T08:00:00Z", "2020-12-28", "2021-01-04", "2020-W53"},
+		{ScheduleWeekly, "2025-03-03T01:00:00+02:00", "2025-02-17", "2025-02-24", "2025-W08"},
+		{ScheduleMonthly, "2025-03-15T12:00:00Z", "2025-02-01", "2025-03-01", "2025-02"},
+		{ScheduleMonthly, "2025-03-01T00:00:00Z", "2025-02-01", "2025-03-01", "2025-02"},
+		{ScheduleMonthly, "2025-01-10T12:00:00Z", "2024-12-01", "2025-01-01", "2024-12"},
+		{ScheduleMonthly, "2024-03-31T23:00:00Z", "2024-02-01", "2024-03-01", "2024-02"},
+		{ScheduleMonthly, "2025-04-01T01:00:00+02:00", "2025-02-01", "2025-03-01", "2025-02"},
+	}
+
+	for _, tt := range tests {
+		now, err := time.Parse(time.RFC3339, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		since, until, key := PreviousPeriod(tt.schedule, now)
+		if got := since.Format("2006-01-02"); got != tt.since || since.Location() != time.UTC {
+			t.Errorf("PreviousPeriod(%s, %s) since = %v, want %s UTC", tt.schedule, tt.now, since, tt.since)
+		}
+		if got := until.Format("2006-01-02"); got != tt.until {
+			t.Errorf("PreviousPeriod(%s, %s) until = %s, want %s", tt.schedule, tt.now, got, tt.until)
+		}
+		if key != tt.key {
+			t.Errorf("PreviousPeriod(%s, %s) key = %s, want %s", tt.schedule, tt.now, key, tt.key)
+		}
+	}
+}
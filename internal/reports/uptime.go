@@ -0,0 +1,201 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Downtime is a period during which a container was not running
+type Downtime struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Planned         bool      `json:"planned"`
+	Reason          string    `json:"reason"`
+}
+
+// ContainerUptime is the availability of a single container in a window
+type ContainerUptime struct {
+	Container           string     `json:"container"`
+	MonitoredSeconds    float64    `json:"monitored_seconds"`
+	UptimeSeconds       float64    `json:"uptime_seconds"`
+	DowntimeSeconds     float64    `json:"downtime_seconds"`
+	UnplannedSeconds    float64    `json:"unplanned_downtime_seconds"`
+	UptimePercent       float64    `json:"uptime_percent"`
+	AvailabilityPercent float64    `json:"availability_percent"`
+	Incidents           int        `json:"incidents"`
+	DowntimeIntervals   []Downtime `json:"downtime"`
+}
+
+// Report is an uptime report for all containers over a window
+type Report struct {
+	Since       time.Time          `json:"since"`
+	Until       time.Time          `json:"until"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Containers  []*ContainerUptime `json:"containers"`
+}
+
+// lifecycleEvent is a start or die row from the events log
+type lifecycleEvent struct {
+	ContainerName string         `db:"container_name"`
+	EventType     string         `db:"event_type"`
+	Status        string         `db:"status"`
+	Message       string         `db:"message"`
+	Timestamp     types.DateTime `db:"timestamp"`
+}
+
+// Uptime builds an uptime report from the start and die history in the
+// events log. Containers are grouped by name so redeploys, which get a new
+// container ID, count towards the same service. Time before the first known
+// event of a container is not monitored and is excluded from percentages.
+func Uptime(db *database.Database, since, until time.Time, container string) (*Report, error) {
+	from, err := types.ParseDateTime(since)
+	if err != nil {
+		return nil, err
+	}
+	to, err := types.ParseDateTime(until)
+	if err != nil {
+		return nil, err
+	}
+
+	params := dbx.Params{"since": from.String(), "until": to.String(), "container": container}
+	containerClause := ""
+	if container != "" {
+		containerClause = "AND e.container_name = {:container}"
+	}
+
+	// The last event before the window tells us the state at its start
+	var rows []lifecycleEvent
+	err = db.App().Dao().DB().NewQuery(`
+		SELECT e.container_name, e.event_type, e.status, e.message, e.timestamp
+		FROM events_log e
+		WHERE e.event_type IN ('start', 'die') ` + containerClause + `
+		AND (
+			(e.timestamp >= {:since} AND e.timestamp < {:until})
+			OR e.timestamp = (
+				SELECT MAX(x.timestamp) FROM events_log x
+				WHERE x.container_name = e.container_name
+				AND x.event_type IN ('start', 'die')
+				AND x.timestamp < {:since}
+			)
+		)
+		ORDER BY e.container_name, e.timestamp`).
+		Bind(params).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]lifecycleEvent)
+	var names []string
+	for _, row := range rows {
+		if _, ok := grouped[row.ContainerName]; !ok {
+			names = append(names, row.ContainerName)
+		}
+		grouped[row.ContainerName] = append(grouped[row.ContainerName], row)
+	}
+	sort.Strings(names)
+
+	report := &Report{
+		Since:       since.UTC(),
+		Until:       until.UTC(),
+		GeneratedAt: time.Now().UTC(),
+		Containers:  []*ContainerUptime{},
+	}
+
+	// Time that has not happened yet is not monitored
+	end := until
+	if now := time.Now(); now.Before(end) {
+		end = now
+	}
+
+	for _, name := range names {
+		report.Containers = append(report.Containers, containerUptime(name, grouped[name], since, end))
+	}
+
+	return report, nil
+}
+
+// containerUptime walks a container's lifecycle events in time order
+func containerUptime(name string, events []lifecycleEvent, since, until time.Time) *ContainerUptime {
+	result := &ContainerUptime{
+		Container:         name,
+		DowntimeIntervals: []Downtime{},
+	}
+
+	var cursor time.Time // zero until the state is known
+	up := false
+	var down *Downtime
+
+	advance := func(to time.Time) {
+		if cursor.IsZero() || !to.After(cursor) {
+			return
+		}
+		d := to.Sub(cursor).Seconds()
+		result.MonitoredSeconds += d
+		if up {
+			result.UptimeSeconds += d
+		} else {
+			result.DowntimeSeconds += d
+			if down != nil && !down.Planned {
+				result.UnplannedSeconds += d
+			}
+		}
+	}
+
+	closeDowntime := func(at time.Time) {
+		if down == nil {
+			return
+		}
+		down.End = at
+		down.DurationSeconds = at.Sub(down.Start).Seconds()
+		result.DowntimeIntervals = append(result.DowntimeIntervals, *down)
+		down = nil
+	}
+
+	for _, event := range events {
+		at := event.Timestamp.Time()
+		if at.Before(since) {
+			at = since
+		}
+
+		advance(at)
+		if cursor.IsZero() || at.After(cursor) {
+			cursor = at
+		}
+
+		switch event.EventType {
+		case "start":
+			closeDowntime(at)
+			up = true
+		case "die":
+			if !up && down != nil {
+				continue
+			}
+			up = false
+			down = &Downtime{
+				Start:   at,
+				Planned: event.Status != "failure",
+				Reason:  event.Message,
+			}
+			// Outages already in progress when the window starts are not new incidents
+			if !down.Planned && !event.Timestamp.Time().Before(since) {
+				result.Incidents++
+			}
+		}
+	}
+
+	advance(until)
+	closeDowntime(until)
+
+	if result.MonitoredSeconds > 0 {
+		result.UptimePercent = 100 * result.UptimeSeconds / result.MonitoredSeconds
+		result.AvailabilityPercent = 100 * (result.MonitoredSeconds - result.UnplannedSeconds) / result.MonitoredSeconds
+	}
+
+	return result
+}
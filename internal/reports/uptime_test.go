@@ -0,0 +1,161 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// uptimeStart is the start of the window in the containerUptime tests
+var uptimeStart = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+// lifecycle creates an event at an offset in hours from uptimeStart
+func lifecycle(eventType, status string, hours float64) lifecycleEvent {
+	at, _ := types.ParseDateTime(uptimeStart.Add(time.Duration(hours * float64(time.Hour))))
+	return lifecycleEvent{
+		ContainerName: "web",
+		EventType:     eventType,
+		Status:        status,
+		Message:       eventType + " " + status,
+		Timestamp:     at,
+	}
+}
+
+func TestContainerUptime(t *testing.T) {
+	// The window is 10 hours long
+	until := uptimeStart.Add(10 * time.Hour)
+
+	tests := []struct {
+		name      string
+		events    []lifecycleEvent
+		monitored float64 // hours
+		uptime    float64
+		unplanned float64
+		incidents int
+		downtime  [][2]float64 // start and end offsets in hours
+	}{
+		{
+			name:      "no events",
+			events:    nil,
+			monitored: 0,
+		},
+		{
+			name:      "running before the window",
+			events:    []lifecycleEvent{lifecycle("start", "success", -2)},
+			monitored: 10,
+			uptime:    10,
+		},
+		{
+			name:      "first event inside the window",
+			events:    []lifecycleEvent{lifecycle("start", "success", 2)},
+			monitored: 8,
+			uptime:    8,
+		},
+		{
+			name: "outage in progress when the window opens",
+			events: []lifecycleEvent{
+				lifecycle("die", "failure", -1),
+				lifecycle("start", "success", 2),
+			},
+			monitored: 10,
+			uptime:    8,
+			unplanned: 2,
+			incidents: 0,
+			downtime:  [][2]float64{{0, 2}},
+		},
+		{
+			name: "outage inside the window",
+			events: []lifecycleEvent{
+				lifecycle("start", "success", -1),
+				lifecycle("die", "failure", 3),
+				lifecycle("start", "success", 4),
+			},
+			monitored: 10,
+			uptime:    9,
+			unplanned: 1,
+			incidents: 1,
+			downtime:  [][2]float64{{3, 4}},
+		},
+		{
+			name: "repeated die events",
+			events: []lifecycleEvent{
+				lifecycle("start", "success", -1),
+				lifecycle("die", "failure", 1),
+				lifecycle("die", "failure", 2),
+				lifecycle("die", "success", 3),
+				lifecycle("start", "success", 4),
+			},
+			monitored: 10,
+			uptime:    7,
+			unplanned: 3,
+			incidents: 1,
+			downtime:  [][2]float64{{1, 4}},
+		},
+		{
+			name: "planned stop until the end",
+			events: []lifecycleEvent{
+				lifecycle("start", "success", -1),
+				lifecycle("die", "success", 5),
+			},
+			monitored: 10,
+			uptime:    5,
+			unplanned: 0,
+			downtime:  [][2]float64{{5, 10}},
+		},
+		{
+			name: "several outages",
+			events: []lifecycleEvent{
+				lifecycle("start", "success", 1),
+				lifecycle("die", "failure", 2),
+				lifecycle("start", "success", 2.5),
+				lifecycle("die", "failure", 6),
+				lifecycle("start", "success", 8),
+			},
+			monitored: 9,
+			uptime:    6.5,
+			unplanned: 2.5,
+			incidents: 2,
+			downtime:  [][2]float64{{2, 2.5}, {6, 8}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := containerUptime("web", tt.events, uptimeStart, until)
+
+		if hours := got.MonitoredSeconds / 3600; hours != tt.monitored {
+			t.Errorf("%s: monitored = %vh, want %vh", tt.name, hours, tt.monitored)
+		}
+		if hours := got.UptimeSeconds / 3600; hours != tt.uptime {
+			t.Errorf("%s: uptime = %vh, want %vh", tt.name, hours, tt.uptime)
+		}
+		if hours := got.UnplannedSeconds / 3600; hours != tt.unplanned {
+			t.Errorf("%s: unplanned downtime = %vh, want %vh", tt.name, hours, tt.unplanned)
+		}
+		if got.Incidents != tt.incidents {
+			t.Errorf("%s: incidents = %d, want %d", tt.name, got.Incidents, tt.incidents)
+		}
+
+		if len(got.DowntimeIntervals) != len(tt.downtime) {
+			t.Errorf("%s: downtime = %+v, want %v", tt.name, got.DowntimeIntervals, tt.downtime)
+			continue
+		}
+		for i, want := range tt.downtime {
+			interval := got.DowntimeIntervals[i]
+			start := interval.Start.Sub(uptimeStart).Hours()
+			end := interval.End.Sub(uptimeStart).Hours()
+			if start != want[0] || end != want[1] {
+				t.Errorf("%s: downtime[%d] = %vh to %vh, want %vh to %vh", tt.name, i, start, end, want[0], want[1])
+			}
+		}
+
+		if tt.monitored > 0 {
+			wantUptime := 100 * tt.uptime / tt.monitored
+			wantAvailability := 100 * (tt.monitored - tt.unplanned) / tt.monitored
+			if got.UptimePercent != wantUptime || got.AvailabilityPercent != wantAvailability {
+				t.Errorf("%s: uptime %v%%, availability %v%%, want %v%% and %v%%",
+					tt.name, got.UptimePercent, got.AvailabilityPercent, wantUptime, wantAvailability)
+			}
+		}
+	}
+}
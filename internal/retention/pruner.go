@@ -0,0 +1,326 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/config"
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// batchSize is how many events are archived and deleted at a time
+const batchSize = 1000
+
+// Rule limits how many events are kept. Zero values disable a limit.
+type Rule struct {
+	MaxAge  time.Duration
+	MaxRows int
+}
+
+// enabled reports whether the rule limits anything
+func (r Rule) enabled() bool {
+	return r.MaxAge > 0 || r.MaxRows > 0
+}
+
+// Policy is the retention policy for the events log. Statuses with their own
+// rule are pruned separately from the default rule.
+type Policy struct {
+	Default    Rule
+	Statuses   map[string]Rule
+	ArchiveDir string
+}
+
+// Enabled reports whether the policy would prune anything
+func (p Policy) Enabled() bool {
+	if p.Default.enabled() {
+		return true
+	}
+	for _, rule := range p.Statuses {
+		if rule.enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyFromConfig builds the retention policy from the configuration
+func PolicyFromConfig(cfg *config.Config) Policy {
+	policy := Policy{
+		Default:    Rule{MaxAge: cfg.RetentionMaxAge, MaxRows: cfg.RetentionMaxRows},
+		Statuses:   make(map[string]Rule),
+		ArchiveDir: cfg.RetentionArchiveDir,
+	}
+
+	for status, maxAge := range cfg.RetentionStatusMaxAge {
+		rule := policy.Statuses[status]
+		rule.MaxAge = maxAge
+		policy.Statuses[status] = rule
+	}
+	for status, maxRows := range cfg.RetentionStatusMaxRows {
+		rule := policy.Statuses[status]
+		rule.MaxRows = maxRows
+		policy.Statuses[status] = rule
+	}
+
+	return policy
+}
+
+// Result summarizes a pruning run
+type Result struct {
+	Deleted  int    `json:"deleted"`
+	Archived int    `json:"archived"`
+	Archive  string `json:"archive,omitempty"`
+}
+
+// Pruner periodically removes old events according to a retention policy
+type Pruner struct {
+	db         *database.Database
+	policy     Policy
+	interval   time.Duration
+	mu         sync.Mutex
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// NewPruner creates a new pruner using the configured retention policy
+func NewPruner(db *database.Database, cfg *config.Config) *Pruner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pruner{
+		db:         db,
+		policy:     PolicyFromConfig(cfg),
+		interval:   cfg.RetentionInterval,
+		ctx:        ctx,
+		cancelFunc: cancel,
+	}
+}
+
+// Policy returns the configured retention policy
+func (p *Pruner) Policy() Policy {
+	return p.policy
+}
+
+// Start runs the pruning job on its interval until stopped
+func (p *Pruner) Start() {
+	if !p.policy.Enabled() || p.interval <= 0 {
+		log.Println("Event retention is disabled, events are kept forever")
+		return
+	}
+
+	log.Printf("🧹 Pruning events every %s", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.runScheduled()
+
+		select {
+		case <-ticker.C:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// runScheduled runs a scheduled prune and logs the outcome
+func (p *Pruner) runScheduled() {
+	result, err := p.Prune(p.policy)
+	if err != nil {
+		log.Printf("Error pruning events: %v", err)
+		return
+	}
+	if result.Deleted > 0 {
+		log.Printf("🧹 Pruned %d events", result.Deleted)
+	}
+}
+
+// Stop stops the pruning job
+func (p *Pruner) Stop() {
+	p.cancelFunc()
+}
+
+// Prune applies a retention policy once
+func (p *Pruner) Prune(policy Policy) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	run := &pruneRun{
+		pruner: p,
+		policy: policy,
+		result: &Result{},
+	}
+	defer run.closeArchive()
+
+	var overridden []any
+	for status, rule := range policy.Statuses {
+		overridden = append(overridden, status)
+		if err := run.apply(dbx.HashExp{"status": status}, rule); err != nil {
+			return run.result, err
+		}
+	}
+
+	var others dbx.Expression = dbx.NewExp("1=1")
+	if len(overridden) > 0 {
+		others = dbx.NotIn("status", overridden...)
+	}
+	if err := run.apply(others, policy.Default); err != nil {
+		return run.result, err
+	}
+
+	return run.result, nil
+}
+
+// pruneRun holds the state of a single Prune call
+type pruneRun struct {
+	pruner  *Pruner
+	policy  Policy
+	result  *Result
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+// apply removes events in scope that exceed the rule's limits
+func (r *pruneRun) apply(scope dbx.Expression, rule Rule) error {
+	if rule.MaxAge > 0 {
+		cutoff, err := types.ParseDateTime(time.Now().Add(-rule.MaxAge))
+		if err != nil {
+			return err
+		}
+		expired := dbx.And(scope, dbx.NewExp("timestamp < {:cutoff}", dbx.Params{"cutoff": cutoff.String()}))
+		if err := r.remove(expired, -1); err != nil {
+			return err
+		}
+	}
+
+	if rule.MaxRows > 0 {
+		var total int
+		err := r.pruner.db.App().Dao().DB().
+			Select("count(*)").
+			From("events_log").
+			Where(scope).
+			Row(&total)
+		if err != nil {
+			return err
+		}
+		if excess := total - rule.MaxRows; excess > 0 {
+			if err := r.remove(scope, excess); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove archives and deletes the oldest matching events in batches. A
+// negative limit removes every match.
+func (r *pruneRun) remove(where dbx.Expression, limit int) error {
+	dao := r.pruner.db.App().Dao()
+
+	collection, err := dao.FindCollectionByNameOrId("events_log")
+	if err != nil {
+		return err
+	}
+
+	for limit != 0 {
+		size := batchSize
+		if limit > 0 && limit < size {
+			size = limit
+		}
+
+		records := []*models.Record{}
+		err := dao.RecordQuery(collection).
+			AndWhere(where).
+			OrderBy("timestamp ASC", "id ASC").
+			Limit(int64(size)).
+			All(&records)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		if r.policy.ArchiveDir != "" {
+			if err := r.archive(records); err != nil {
+				return fmt.Errorf("failed to archive events: %w", err)
+			}
+		}
+
+		ids := make([]any, len(records))
+		for i, record := range records {
+			ids[i] = record.Id
+		}
+
+		if _, err := dao.DB().Delete("events_log", dbx.In("id", ids...)).Execute(); err != nil {
+			return err
+		}
+
+		r.result.Deleted += len(records)
+		if limit > 0 {
+			limit -= len(records)
+		}
+		if len(records) < size {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// archive appends records to this run's compressed NDJSON archive
+func (r *pruneRun) archive(records []*models.Record) error {
+	if r.encoder == nil {
+		if err := os.MkdirAll(r.policy.ArchiveDir, 0755); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("events-%s.ndjson.gz", time.Now().UTC().Format("20060102T150405Z"))
+		path := filepath.Join(r.policy.ArchiveDir, name)
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+
+		r.file = file
+		r.gz = gzip.NewWriter(file)
+		r.encoder = json.NewEncoder(r.gz)
+		r.result.Archive = path
+	}
+
+	for _, record := range records {
+		if err := r.encoder.Encode(record); err != nil {
+			return err
+		}
+		r.result.Archived++
+	}
+
+	// Flush so archived events are on disk before they are deleted
+	return r.gz.Flush()
+}
+
+// closeArchive finishes the archive file, if one was opened
+func (r *pruneRun) closeArchive() {
+	if r.gz != nil {
+		if err := r.gz.Close(); err != nil {
+			log.Printf("Error closing event archive: %v", err)
+		}
+	}
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			log.Printf("Error closing event archive: %v", err)
+		}
+	}
+}
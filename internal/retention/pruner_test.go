@@ -0,0 +1,353 @@
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/migrate"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// seed is an event inserted before pruning, identified by its message
+type seed struct {
+	message string
+	status  string
+	age     time.Duration
+}
+
+// newTestPruner creates a pruner on a temporary PocketBase app
+func newTestPruner(t *testing.T) *Pruner {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	// PocketBase reads its data directory and debug mode from the command line
+	args := os.Args
+	os.Args = []string{args[0], "--dir", filepath.Join(dir, "pb_data"), "--debug=false"}
+	t.Cleanup(func() { os.Args = args })
+
+	pb := pocketbase.New()
+	if err := pb.Bootstrap(); err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	defer pb.ResetBootstrapState()
+
+	runner, err := migrate.NewRunner(pb.DB(), migrations.AppMigrations)
+	if err != nil {
+		t.Fatalf("migrate.NewRunner() error = %v", err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations error = %v", err)
+	}
+
+	db, err := database.New(dir)
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.App().ResetBootstrapState() })
+
+	return &Pruner{db: db}
+}
+
+// insertEvents replaces the events log with the seeded events
+func insertEvents(t *testing.T, p *Pruner, events []seed) {
+	t.Helper()
+
+	err := p.db.App().Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		if _, err := txDao.DB().Delete("events_log", dbx.NewExp("1=1")).Execute(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, event := range events {
+			timestamp, err := types.ParseDateTime(now.Add(-event.age))
+			if err != nil {
+				return err
+			}
+			_, err = txDao.DB().Insert("events_log", dbx.Params{
+				"id":           fmt.Sprintf("event%010d", i),
+				"container_id": "abc123",
+				"event_type":   "die",
+				"status":       event.status,
+				"message":      event.message,
+				"timestamp":    timestamp.String(),
+				"created":      timestamp.String(),
+				"updated":      timestamp.String(),
+			}).Execute()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("inserting events: %v", err)
+	}
+}
+
+// remainingEvents returns the messages of the events left, oldest first
+func remainingEvents(t *testing.T, p *Pruner) []string {
+	t.Helper()
+
+	var messages []string
+	err := p.db.App().Dao().DB().
+		Select("message").
+		From("events_log").
+		OrderBy("timestamp ASC", "id ASC").
+		Column(&messages)
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	return messages
+}
+
+// seeds creates n events with the same status, one minute apart and the
+// oldest first
+func seeds(prefix, status string, n int, oldest time.Duration) []seed {
+	events := make([]seed, n)
+	for i := range events {
+		events[i] = seed{
+			message: fmt.Sprintf("%s%d", prefix, i),
+			status:  status,
+			age:     oldest - time.Duration(i)*time.Minute,
+		}
+	}
+	return events
+}
+
+func TestPrune(t *testing.T) {
+	p := newTestPruner(t)
+
+	events := []seed{
+		{"old-success", "success", 48 * time.Hour},
+		{"old-failure", "failure", 47 * time.Hour},
+		{"old-info", "info", 46 * time.Hour},
+		{"new-success", "success", time.Hour},
+		{"new-failure", "failure", 50 * time.Minute},
+		{"new-info", "info", 40 * time.Minute},
+	}
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		want    []string
+		deleted int
+	}{
+		{
+			name:    "no limits",
+			policy:  Policy{},
+			want:    []string{"old-success", "old-failure", "old-info", "new-success", "new-failure", "new-info"},
+			deleted: 0,
+		},
+		{
+			name:    "default max age",
+			policy:  Policy{Default: Rule{MaxAge: 24 * time.Hour}},
+			want:    []string{"new-success", "new-failure", "new-info"},
+			deleted: 3,
+		},
+		{
+			name:    "status max age only",
+			policy:  Policy{Statuses: map[string]Rule{"success": {MaxAge: 24 * time.Hour}}},
+			want:    []string{"old-failure", "old-info", "new-success", "new-failure", "new-info"},
+			deleted: 1,
+		},
+		{
+			name: "status rule excluded from default",
+			policy: Policy{
+				Default:  Rule{MaxAge: 24 * time.Hour},
+				Statuses: map[string]Rule{"failure": {MaxAge: 72 * time.Hour}},
+			},
+			want:    []string{"old-failure", "new-success", "new-failure", "new-info"},
+			deleted: 2,
+		},
+		{
+			name: "status rule without limits keeps its events",
+			policy: Policy{
+				Default:  Rule{MaxRows: 1},
+				Statuses: map[string]Rule{"failure": {}},
+			},
+			want:    []string{"old-failure", "new-failure", "new-info"},
+			deleted: 3,
+		},
+		{
+			name:    "default max rows",
+			policy:  Policy{Default: Rule{MaxRows: 4}},
+			want:    []string{"old-info", "new-success", "new-failure", "new-info"},
+			deleted: 2,
+		},
+		{
+			name:    "max rows above total",
+			policy:  Policy{Default: Rule{MaxRows: 10}},
+			want:    []string{"old-success", "old-failure", "old-info", "new-success", "new-failure", "new-info"},
+			deleted: 0,
+		},
+		{
+			name: "status and default max rows counted separately",
+			policy: Policy{
+				Default:  Rule{MaxRows: 1},
+				Statuses: map[string]Rule{"failure": {MaxRows: 1}, "success": {MaxRows: 2}},
+			},
+			want:    []string{"old-success", "new-success", "new-failure", "new-info"},
+			deleted: 2,
+		},
+		{
+			name: "max age and max rows combined",
+			policy: Policy{
+				Default:  Rule{MaxAge: 24 * time.Hour, MaxRows: 1},
+				Statuses: map[string]Rule{"failure": {MaxRows: 1}},
+			},
+			want:    []string{"new-failure", "new-info"},
+			deleted: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		insertEvents(t, p, events)
+
+		result, err := p.Prune(tt.policy)
+		if err != nil {
+			t.Fatalf("%s: Prune() error = %v", tt.name, err)
+		}
+		if result.Deleted != tt.deleted {
+			t.Errorf("%s: Prune().Deleted = %d, want %d", tt.name, result.Deleted, tt.deleted)
+		}
+		if got := remainingEvents(t, p); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: remaining events = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPruneBatches(t *testing.T) {
+	p := newTestPruner(t)
+	total := 2*batchSize + 5
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		deleted int
+	}{
+		{"max age", Policy{Default: Rule{MaxAge: time.Hour}}, total},
+		{"max rows", Policy{Default: Rule{MaxRows: 3}}, total - 3},
+		{"max rows within one batch", Policy{Default: Rule{MaxRows: total - 10}}, 10},
+	}
+
+	for _, tt := range tests {
+		// Events are a minute apart, so all are older than an hour
+		insertEvents(t, p, seeds("event", "info", total, time.Duration(total)*time.Minute+2*time.Hour))
+
+		result, err := p.Prune(tt.policy)
+		if err != nil {
+			t.Fatalf("%s: Prune() error = %v", tt.name, err)
+		}
+		if result.Deleted != tt.deleted {
+			t.Errorf("%s: Prune().Deleted = %d, want %d", tt.name, result.Deleted, tt.deleted)
+		}
+
+		// The oldest events go first
+		got := remainingEvents(t, p)
+		want := seeds("event", "info", total, 0)[tt.deleted:]
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d events remain, want %d", tt.name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i].message {
+				t.Errorf("%s: remaining event %d = %s, want %s", tt.name, i, got[i], want[i].message)
+				break
+			}
+		}
+	}
+}
+
+func TestPruneArchive(t *testing.T) {
+	p := newTestPruner(t)
+	dir := filepath.Join(t.TempDir(), "archive")
+
+	events := append(seeds("old", "info", batchSize+2, 72*time.Hour), seeds("new", "info", 3, time.Hour)...)
+	insertEvents(t, p, events)
+
+	result, err := p.Prune(Policy{Default: Rule{MaxAge: 24 * time.Hour}, ArchiveDir: dir})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Deleted != batchSize+2 || result.Archived != result.Deleted {
+		t.Errorf("Prune() = %+v, want %d deleted and archived", result, batchSize+2)
+	}
+	if filepath.Dir(result.Archive) != dir {
+		t.Errorf("Prune().Archive = %q, want a file in %q", result.Archive, dir)
+	}
+
+	// Every deleted event is in the archive, across batches
+	file, err := os.Open(result.Archive)
+	if err != nil {
+		t.Fatalf("opening archive: %v", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+
+	var archived []string
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var event struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decoding archived event: %v", err)
+		}
+		archived = append(archived, event.Message)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+
+	var want []string
+	for _, event := range events[:batchSize+2] {
+		want = append(want, event.message)
+	}
+	sort.Strings(archived)
+	sort.Strings(want)
+	if fmt.Sprint(archived) != fmt.Sprint(want) {
+		t.Errorf("archived %d events, want the %d deleted ones", len(archived), len(want))
+	}
+	if got := remainingEvents(t, p); fmt.Sprint(got) != "[new0 new1 new2]" {
+		t.Errorf("remaining events = %v, want [new0 new1 new2]", got)
+	}
+}
+
+func TestPruneArchiveFailureKeepsEvents(t *testing.T) {
+	p := newTestPruner(t)
+
+	// A file where the archive directory should be makes archiving fail
+	dir := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	insertEvents(t, p, seeds("old", "info", 3, 72*time.Hour))
+
+	result, err := p.Prune(Policy{Default: Rule{MaxAge: 24 * time.Hour}, ArchiveDir: dir})
+	if err == nil {
+		t.Fatalf("Prune() error = nil, want an archive error")
+	}
+	if result.Deleted != 0 {
+		t.Errorf("Prune().Deleted = %d, want 0", result.Deleted)
+	}
+	if got := remainingEvents(t, p); len(got) != 3 {
+		t.Errorf("remaining events = %v, want all 3", got)
+	}
+}
@@ -0,0 +1,390 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatlirmorina/notifypipe/internal/database"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Bucket intervals supported by TimeSeries
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+// maxBuckets limits the size of a time series response
+const maxBuckets = 1000
+
+// Service computes statistics from the events log using SQL aggregation
+type Service struct {
+	db *database.Database
+}
+
+// NewService creates a new statistics service
+func NewService(db *database.Database) *Service {
+	return &Service{db: db}
+}
+
+// Bucket is one interval of a time series
+type Bucket struct {
+	Time                time.Time      `json:"time"`
+	Events              map[string]int `json:"events"`
+	NotificationsSent   int            `json:"notifications_sent"`
+	NotificationsFailed int            `json:"notifications_failed"`
+}
+
+// TimeSeries counts events by status and notifications by outcome per hour
+// or day between since and until. Container may be a name or ID prefix.
+func (s *Service) TimeSeries(interval string, since, until time.Time, container string) ([]*Bucket, error) {
+	var step time.Duration
+	var prefix int
+	switch interval {
+	case IntervalHour:
+		step, prefix = time.Hour, len("2006-01-02 15")
+	case IntervalDay:
+		step, prefix = 24*time.Hour, len("2006-01-02")
+	default:
+		return nil, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	since = since.UTC().Truncate(step)
+	until = until.UTC()
+	if !until.After(since) {
+		return nil, fmt.Errorf("until must be after since")
+	}
+	if until.Sub(since)/step >= maxBuckets {
+		return nil, fmt.Errorf("too many buckets, use a larger interval or a shorter range")
+	}
+
+	// Pre-fill every bucket so gaps show up as zeros
+	var buckets []*Bucket
+	index := make(map[string]*Bucket)
+	for t := since; t.Before(until); t = t.Add(step) {
+		bucket := &Bucket{Time: t, Events: make(map[string]int)}
+		buckets = append(buckets, bucket)
+		index[bucketKey(t, prefix)] = bucket
+	}
+
+	where, err := rangeExpr(since, until)
+	if err != nil {
+		return nil, err
+	}
+	eventsWhere := where
+	if container != "" {
+		eventsWhere = dbx.And(where, containerExpr(container))
+	}
+
+	var eventRows []struct {
+		Bucket string `db:"bucket"`
+		Status string `db:"status"`
+		Total  int    `db:"total"`
+	}
+	err = s.db.App().Dao().DB().
+		Select(fmt.Sprintf("substr(timestamp, 1, %d) AS bucket", prefix), "status", "count(*) AS total").
+		From("events_log").
+		Where(eventsWhere).
+		GroupBy("bucket", "status").
+		All(&eventRows)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range eventRows {
+		if bucket, ok := index[row.Bucket]; ok {
+			bucket.Events[row.Status] += row.Total
+		}
+	}
+
+	// Deliveries are not tied to a container
+	if container == "" {
+		var deliveryRows []struct {
+			Bucket  string `db:"bucket"`
+			Success bool   `db:"success"`
+			Total   int    `db:"total"`
+		}
+		err = s.db.App().Dao().DB().
+			Select(fmt.Sprintf("substr(timestamp, 1, %d) AS bucket", prefix), "success", "count(*) AS total").
+			From("deliveries").
+			Where(where).
+			GroupBy("bucket", "success").
+			All(&deliveryRows)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range deliveryRows {
+			bucket, ok := index[row.Bucket]
+			if !ok {
+				continue
+			}
+			if row.Success {
+				bucket.NotificationsSent += row.Total
+			} else {
+				bucket.NotificationsFailed += row.Total
+			}
+		}
+	}
+
+	return buckets, nil
+}
+
+// Rate is a count of failures out of a total
+type Rate struct {
+	Name        string  `json:"name"`
+	Total       int     `json:"total"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// Overview summarizes activity since the given time
+type Overview struct {
+	Events                  int     `json:"events"`
+	Failures                int     `json:"failures"`
+	NotificationsSent       int     `json:"notifications_sent"`
+	NotificationsFailed     int     `json:"notifications_failed"`
+	NotificationSuccessRate float64 `json:"notification_success_rate"`
+	FailingContainers       []Rate  `json:"failing_containers"`
+	FailingImages           []Rate  `json:"failing_images"`
+}
+
+// Overview computes failure and delivery totals since the given time,
+// including the containers and images with the most failures
+func (s *Service) Overview(since time.Time, limit int) (*Overview, error) {
+	where, err := rangeExpr(since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.db.App().Dao().DB()
+	overview := &Overview{
+		FailingContainers: []Rate{},
+		FailingImages:     []Rate{},
+	}
+
+	var events struct {
+		Total    int `db:"total"`
+		Failures int `db:"failures"`
+	}
+	err = db.Select("count(*) AS total", "COALESCE(SUM(status = 'failure'), 0) AS failures").
+		From("events_log").
+		Where(where).
+		One(&events)
+	if err != nil {
+		return nil, err
+	}
+	overview.Events = events.Total
+	overview.Failures = events.Failures
+
+	var deliveries struct {
+		Total int `db:"total"`
+		Sent  int `db:"sent"`
+	}
+	err = db.Select("count(*) AS total", "COALESCE(SUM(success), 0) AS sent").
+		From("deliveries").
+		Where(where).
+		One(&deliveries)
+	if err != nil {
+		return nil, err
+	}
+	overview.NotificationsSent = deliveries.Sent
+	overview.NotificationsFailed = deliveries.Total - deliveries.Sent
+	if deliveries.Total > 0 {
+		overview.NotificationSuccessRate = float64(deliveries.Sent) / float64(deliveries.Total)
+	}
+
+	var containers []Rate
+	err = db.Select("container_name AS name", "count(*) AS total", "SUM(status = 'failure') AS failures").
+		From("events_log").
+		Where(where).
+		GroupBy("container_name").
+		Having(dbx.NewExp("failures > 0")).
+		OrderBy("failures DESC", "total DESC").
+		Limit(int64(limit)).
+		All(&containers)
+	if err != nil {
+		return nil, err
+	}
+	overview.FailingContainers = withRates(containers)
+
+	// Events logged before images were recorded fall back to the container.
+	// The alias must not clash with the name and image columns of the join.
+	var images []struct {
+		Image    string `db:"failing_image"`
+		Total    int    `db:"total"`
+		Failures int    `db:"failures"`
+	}
+	err = db.Select("COALESCE(NULLIF(e.image, ''), c.image, '') AS failing_image", "count(*) AS total", "SUM(e.status = 'failure') AS failures").
+		From("events_log e").
+		LeftJoin("containers c", dbx.NewExp("c.container_id = e.container_id")).
+		Where(where).
+		GroupBy("failing_image").
+		Having(dbx.NewExp("failures > 0 AND failing_image != ''")).
+		OrderBy("failures DESC", "total DESC").
+		Limit(int64(limit)).
+		All(&images)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		overview.FailingImages = append(overview.FailingImages, Rate{
+			Name:     image.Image,
+			Total:    image.Total,
+			Failures: image.Failures,
+		})
+	}
+	overview.FailingImages = withRates(overview.FailingImages)
+
+	return overview, nil
+}
+
+// ContainerStats describes the reliability of a single container
+type ContainerStats struct {
+	Container   string         `json:"container"`
+	Events      map[string]int `json:"events"`
+	Total       int            `json:"total"`
+	Failures    int            `json:"failures"`
+	Recoveries  int            `json:"recoveries"`
+	FailureRate float64        `json:"failure_rate"`
+	MTBFSeconds float64        `json:"mtbf_seconds"`
+	MTTRSeconds float64        `json:"mttr_seconds"`
+	FirstSeen   *time.Time     `json:"first_seen"`
+	LastSeen    *time.Time     `json:"last_seen"`
+	LastFailure *time.Time     `json:"last_failure"`
+}
+
+// Container computes reliability statistics for a container since the given
+// time. MTTR is the mean time from a failure to the next success; MTBF is
+// the mean time from a recovery to the next failure.
+func (s *Service) Container(container string, since time.Time) (*ContainerStats, error) {
+	where, err := rangeExpr(since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	where = dbx.And(where, containerExpr(container))
+
+	result := &ContainerStats{
+		Container: container,
+		Events:    make(map[string]int),
+	}
+
+	db := s.db.App().Dao().DB()
+
+	var counts []struct {
+		Status string `db:"status"`
+		Total  int    `db:"total"`
+	}
+	err = db.Select("status", "count(*) AS total").
+		From("events_log").
+		Where(where).
+		GroupBy("status").
+		All(&counts)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range counts {
+		result.Events[row.Status] = row.Total
+		result.Total += row.Total
+	}
+	result.Failures = result.Events["failure"]
+	if result.Total > 0 {
+		result.FailureRate = float64(result.Failures) / float64(result.Total)
+	}
+
+	// Only failures and successes matter for MTBF and MTTR
+	var timeline []struct {
+		Status    string         `db:"status"`
+		Timestamp types.DateTime `db:"timestamp"`
+	}
+	err = db.Select("status", "timestamp").
+		From("events_log").
+		Where(dbx.And(where, dbx.In("status", "failure", "success"))).
+		OrderBy("timestamp ASC").
+		All(&timeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var failedAt, recoveredAt time.Time
+	var repair, between time.Duration
+	var betweenCount int
+	for _, entry := range timeline {
+		at := entry.Timestamp.Time()
+		if result.FirstSeen == nil {
+			result.FirstSeen = &at
+		}
+		result.LastSeen = &at
+
+		switch entry.Status {
+		case "failure":
+			result.LastFailure = &at
+			if !failedAt.IsZero() {
+				continue // still down
+			}
+			failedAt = at
+			if !recoveredAt.IsZero() {
+				between += at.Sub(recoveredAt)
+				betweenCount++
+			}
+		case "success":
+			if failedAt.IsZero() {
+				recoveredAt = at
+				continue
+			}
+			repair += at.Sub(failedAt)
+			result.Recoveries++
+			recoveredAt, failedAt = at, time.Time{}
+		}
+	}
+
+	if result.Recoveries > 0 {
+		result.MTTRSeconds = (repair / time.Duration(result.Recoveries)).Seconds()
+	}
+	if betweenCount > 0 {
+		result.MTBFSeconds = (between / time.Duration(betweenCount)).Seconds()
+	}
+
+	return result, nil
+}
+
+// rangeExpr restricts a query to timestamps in [since, until)
+func rangeExpr(since, until time.Time) (dbx.Expression, error) {
+	from, err := types.ParseDateTime(since)
+	if err != nil {
+		return nil, err
+	}
+	to, err := types.ParseDateTime(until)
+	if err != nil {
+		return nil, err
+	}
+	return dbx.NewExp("timestamp >= {:since} AND timestamp < {:until}", dbx.Params{
+		"since": from.String(),
+		"until": to.String(),
+	}), nil
+}
+
+// containerExpr matches a container name or ID prefix
+func containerExpr(container string) dbx.Expression {
+	return dbx.Or(
+		dbx.HashExp{"container_name": container},
+		dbx.Like("container_id", container).Match(false, true),
+	)
+}
+
+// bucketKey formats a time the way SQLite sees the stored timestamp prefix
+func bucketKey(t time.Time, prefix int) string {
+	return t.UTC().Format("2006-01-02 15:04:05")[:prefix]
+}
+
+// withRates fills in the failure rate of each entry
+func withRates(rates []Rate) []Rate {
+	if rates == nil {
+		return []Rate{}
+	}
+	for i := range rates {
+		if rates[i].Total > 0 {
+			rates[i].FailureRate = float64(rates[i].Failures) / float64(rates[i].Total)
+		}
+	}
+	return rates
+}
@@ -17,8 +17,41 @@ document.addEventListener("DOMContentLoaded", () => {
     loadContainers();
     loadEvents();
   }, 30000);
+
+  connectStream();
 });
 
+// Live Updates
+let refreshTimers = {};
+
+function scheduleRefresh(name, fn) {
+  // Collapse bursts of updates into a single reload
+  clearTimeout(refreshTimers[name]);
+  refreshTimers[name] = setTimeout(fn, 500);
+}
+
+function connectStream() {
+  if (!window.EventSource) return;
+
+  const stream = new EventSource(`${API_BASE}/stream`);
+
+  stream.addEventListener("container", () => {
+    scheduleRefresh("containers", loadContainers);
+  });
+
+  stream.addEventListener("event", () => {
+    scheduleRefresh("events", loadEvents);
+    scheduleRefresh("stats", loadStats);
+  });
+
+  stream.addEventListener("delivery", (e) => {
+    const delivery = JSON.parse(e.data).data;
+    if (!delivery.success) {
+      showToast(`Delivery to ${delivery.channel} failed`, "error");
+    }
+  });
+}
+
 // Tab Management
 function showTab(tabName) {
   // Hide all tabs
@@ -64,8 +97,8 @@ async function loadStats() {
 // Load Containers
 async function loadContainers() {
   try {
-    const response = await fetch(`${API_BASE}/containers`);
-    const containers = await response.json();
+    const response = await fetch(`${API_BASE}/containers?per_page=500`);
+    const containers = (await response.json()).items;
 
     const containersList = document.getElementById("containers-list");
 
@@ -87,6 +120,16 @@ async function loadContainers() {
           container.state
         }</span>
                             <span class="text-xs text-gray-500">${container.id.substring(0, 12)}</span>
+                            ${
+                              container.usage
+                                ? `<span class="text-xs text-gray-400">CPU ${container.usage.cpu_percent.toFixed(
+                                    1
+                                  )}% · Memory ${container.usage.memory_percent.toFixed(1)}%</span>`
+                                : ""
+                            }
+                        </div>
+                        <div class="flex items-center mt-3 space-x-2">
+                            ${containerControls(container)}
                         </div>
                     </div>
                     <div class="flex flex-col space-y-2">
@@ -119,6 +162,60 @@ async function loadContainers() {
   }
 }
 
+// Container control buttons for the container's current state
+function containerControls(container) {
+  const actions = container.state === "running" ? ["restart", "stop"] : ["start"];
+  return actions
+    .map(
+      (action) => `
+        <button onclick="controlContainer('${container.id}', '${container.name}', '${action}')"
+                class="px-2 py-1 rounded text-xs bg-dark-bg border border-dark-border text-gray-300 hover:bg-dark-hover capitalize">${action}</button>`
+    )
+    .join("");
+}
+
+// Start, stop or restart a container after confirmation
+async function controlContainer(containerId, name, action) {
+  if (!confirm(`Are you sure you want to ${action} ${name}?`)) return;
+
+  try {
+    const response = await controlFetch(`${API_BASE}/containers/${containerId}/${action}`, {
+      method: "POST",
+    });
+    const data = await response.json();
+
+    if (response.ok) {
+      showToast(data.message, "success");
+      loadContainers();
+    } else {
+      showToast(data.error || `Failed to ${action} container`, "error");
+    }
+  } catch (error) {
+    console.error("Error controlling container:", error);
+    showToast(`Failed to ${action} container`, "error");
+  }
+}
+
+// Fetch a control route with the stored control token, asking for the token
+// when it is missing or rejected
+async function controlFetch(url, options) {
+  const send = () =>
+    fetch(url, {
+      ...options,
+      headers: { ...options.headers, Authorization: `Bearer ${localStorage.getItem("controlToken") || ""}` },
+    });
+
+  let response = await send();
+  if (response.status === 401) {
+    const token = prompt("Control token (CONTROL_TOKENS)");
+    if (token) {
+      localStorage.setItem("controlToken", token);
+      response = await send();
+    }
+  }
+  return response;
+}
+
 // Update Container Settings
 async function updateContainerSettings(containerId, field, value) {
   try {
@@ -168,7 +265,7 @@ async function loadNotifications() {
                 <div class="flex items-center justify-between">
                     <div class="flex-1">
                         <h3 class="font-semibold text-white">${notif.name}</h3>
-                        <p class="text-sm text-gray-400 mt-1">${notif.type}</p>
+                        <p class="text-sm text-gray-400 mt-1">${notif.type} ${channelHealth(notif)}</p>
                         <p class="text-xs text-gray-500 mt-1 font-mono">${maskUrl(notif.url)}</p>
                     </div>
                     <div class="flex items-center space-x-3">
@@ -178,6 +275,9 @@ async function loadNotifications() {
                                    class="w-4 h-4 text-blue-600 bg-dark-bg border-dark-border rounded focus:ring-blue-500">
                             <span class="text-sm text-gray-300">Enabled</span>
                         </label>
+                        <button onclick="testSavedNotification('${notif.id}')" class="text-blue-400 hover:text-blue-300" title="${lastTest(notif)}">
+                            🧪
+                        </button>
                         <button onclick="deleteNotification('${notif.id}')" class="text-red-400 hover:text-red-300">
                             🗑️
                         </button>
@@ -195,8 +295,8 @@ async function loadNotifications() {
 // Load Events
 async function loadEvents() {
   try {
-    const response = await fetch(`${API_BASE}/events`);
-    const events = await response.json();
+    const response = await fetch(`${API_BASE}/events?per_page=50`);
+    const events = (await response.json()).items;
 
     const eventsList = document.getElementById("events-list");
     const recentEvents = document.getElementById("recent-events");
@@ -248,6 +348,14 @@ async function loadEvents() {
 }
 
 // Modal Management
+// Health badge of a notification channel
+function channelHealth(notif) {
+  if (!notif.health || notif.health === "healthy") return "";
+  const color = notif.health === "failing" ? "text-red-400" : "text-yellow-400";
+  const title = notif.last_error ? ` title="${notif.last_error.replace(/"/g, "&quot;")}"` : "";
+  return `<span class="text-xs ${color}"${title}>· ${notif.health} (${notif.consecutive_failures} failed)</span>`;
+}
+
 function showAddNotificationModal() {
   document.getElementById("notification-modal").classList.remove("hidden");
 }
@@ -264,12 +372,13 @@ document.getElementById("notification-form").addEventListener("submit", async (e
   const name = document.getElementById("notification-name").value;
   const type = document.getElementById("notification-type").value;
   const url = document.getElementById("notification-url").value;
+  const config = webhookConfig(type);
 
   try {
     const response = await fetch(`${API_BASE}/notifications`, {
       method: "POST",
       headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({ name, type, url }),
+      body: JSON.stringify({ name, type, url, config }),
     });
 
     if (response.ok) {
@@ -277,6 +386,10 @@ document.getElementById("notification-form").addEventListener("submit", async (e
       hideAddNotificationModal();
       loadNotifications();
       loadStats();
+    } else {
+      const data = await response.json();
+      const fields = Object.values(data.fields || {});
+      showToast(fields.length ? fields.join(". ") : data.error || "Failed to add notification channel", "error");
     }
   } catch (error) {
     console.error("Error adding notification:", error);
@@ -284,9 +397,25 @@ document.getElementById("notification-form").addEventListener("submit", async (e
   }
 });
 
+// Webhook config from the add notification form
+function webhookConfig(type) {
+  if (type !== "webhook") {
+    return undefined;
+  }
+  const secret = document.getElementById("notification-secret").value;
+  return secret ? { secret } : {};
+}
+
+// Show the signing secret only for webhooks
+document.getElementById("notification-type").addEventListener("change", (e) => {
+  document.getElementById("notification-secret-field").classList.toggle("hidden", e.target.value !== "webhook");
+});
+
 // Test Notification
 async function testNotification() {
+  const type = document.getElementById("notification-type").value;
   const url = document.getElementById("notification-url").value;
+  const config = webhookConfig(type);
 
   if (!url) {
     showToast("Please enter a URL", "error");
@@ -297,22 +426,48 @@ async function testNotification() {
     const response = await fetch(`${API_BASE}/notifications/test`, {
       method: "POST",
       headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({ url }),
+      body: JSON.stringify({ type, url, config }),
     });
 
     const data = await response.json();
 
+    if (data.success) {
+      showToast("Test notification sent!", "success");
+    } else {
+      const fields = Object.values(data.fields || {});
+      showToast(`Test failed: ${fields.length ? fields.join(". ") : data.error}`, "error");
+    }
+  } catch (error) {
+    console.error("Error testing notification:", error);
+    showToast("Failed to send test notification", "error");
+  }
+}
+
+// Test Saved Notification
+async function testSavedNotification(id) {
+  try {
+    const response = await fetch(`${API_BASE}/notifications/${id}/test`, { method: "POST" });
+    const data = await response.json();
+
     if (data.success) {
       showToast("Test notification sent!", "success");
     } else {
       showToast(`Test failed: ${data.error}`, "error");
     }
+    loadNotifications();
   } catch (error) {
     console.error("Error testing notification:", error);
     showToast("Failed to send test notification", "error");
   }
 }
 
+// Outcome of the last test of a notification channel
+function lastTest(notif) {
+  if (!notif.last_tested) return "Send a test notification";
+  const outcome = notif.last_test_success ? "succeeded" : `failed: ${notif.last_test_error || ""}`;
+  return `Last test ${formatDate(notif.last_tested)} ${outcome}`.replace(/"/g, "&quot;");
+}
+
 // Toggle Notification
 async function toggleNotification(id, enabled) {
   try {